@@ -14,6 +14,7 @@ import (
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zerrors"
 	"go.uber.org/zap"
 	kubevirtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/yaml"
 )
 
 func main() {
@@ -48,22 +49,35 @@ func main() {
 		)
 	}
 
-	if err := vmCreator.OwnVolumes(vm); err != nil {
-		exit.ExitFromError(OwnVolumesErrorExitCode, err)
-	}
-	runStrategy := cliOptions.GetRunStrategy()
-	if cliOptions.GetStartVMFlag() && kubevirtv1.RunStrategyAlways != kubevirtv1.VirtualMachineRunStrategy(runStrategy) {
-		err := vmCreator.StartVM(vm.Namespace, vm.Name)
-		if err != nil {
-			exit.ExitFromError(StartVMErrorExitCode, err)
-		}
-	}
-
 	results := map[string]string{
 		NameResultName:      vm.Name,
 		NamespaceResultName: vm.Namespace,
 	}
 
+	if cliOptions.IsDryRun() {
+		log.Logger().Info("dry run: the VM was not created", zap.String("dry-run", string(cliOptions.GetDryRunMode())))
+		manifest, err := yaml.Marshal(vm)
+		if err != nil {
+			exit.ExitOrDieFromError(WriteResultsExitCode, err)
+		}
+		if len(manifest) <= ManifestResultMaxSize {
+			results[ManifestResultName] = string(manifest)
+		} else {
+			log.Logger().Warn("the manifest exceeds the size limit of task results and is only printed to the log", zap.Int("size", len(manifest)))
+		}
+	} else {
+		if err := vmCreator.OwnVolumes(vm); err != nil {
+			exit.ExitFromError(OwnVolumesErrorExitCode, err)
+		}
+		runStrategy := cliOptions.GetRunStrategy()
+		if cliOptions.GetStartVMFlag() && kubevirtv1.RunStrategyAlways != kubevirtv1.VirtualMachineRunStrategy(runStrategy) {
+			err := vmCreator.StartVM(vm.Namespace, vm.Name)
+			if err != nil {
+				exit.ExitFromError(StartVMErrorExitCode, err)
+			}
+		}
+	}
+
 	log.Logger().Debug("recording results", zap.Reflect("results", results))
 	if err := res.RecordResults(results); err != nil {
 		exit.ExitOrDieFromError(WriteResultsExitCode, err)
@@ -15,11 +15,23 @@ const (
 const (
 	NameResultName      = "name"
 	NamespaceResultName = "namespace"
+	ManifestResultName  = "manifest"
 )
 
+// ManifestResultMaxSize limits the manifest result, so it fits into the size limit of all task results
+const ManifestResultMaxSize = 3072
+
 type CreationMode string
 
 const (
 	TemplateCreationMode   CreationMode = "TemplateCreationMode"
 	VMManifestCreationMode CreationMode = "VMManifestCreationMode"
 )
+
+type DryRunMode string
+
+const (
+	NoneDryRunMode   DryRunMode = "none"
+	ClientDryRunMode DryRunMode = "client"
+	ServerDryRunMode DryRunMode = "server"
+)
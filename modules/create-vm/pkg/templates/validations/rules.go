@@ -0,0 +1,179 @@
+package validations
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+const jsonPathPrefix = "jsonpath::"
+
+const (
+	integerRule = "integer"
+	stringRule  = "string"
+	regexRule   = "regex"
+	enumRule    = "enum"
+)
+
+// Violation is a validation rule which is not satisfied by a VM
+type Violation struct {
+	Name        string
+	Message     string
+	Reason      string
+	JustWarning bool
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%v: %v: %v", v.Name, v.Message, v.Reason)
+}
+
+// Validate evaluates all validation rules against the VMI template of the VM and returns the violated ones.
+// Rules whose path or valid path do not match any field of the VM are skipped.
+func (t *TemplateValidations) Validate(vm *kubevirtv1.VirtualMachine) ([]Violation, error) {
+	if vm.Spec.Template == nil {
+		return nil, nil
+	}
+
+	// paths of the rules are relative to the VMI
+	vmi, err := runtime.DefaultUnstructuredConverter.ToUnstructured(vm.Spec.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []Violation
+	for _, validation := range t.validations {
+		if reason := validation.evaluate(vmi); reason != "" {
+			violations = append(violations, Violation{
+				Name:        validation.Name,
+				Message:     validation.Message,
+				Reason:      reason,
+				JustWarning: validation.JustWarning,
+			})
+		}
+	}
+	return violations, nil
+}
+
+// evaluate returns the reason why the rule is not satisfied or an empty string if it is
+func (c *CommonTemplateValidation) evaluate(vmi map[string]interface{}) string {
+	if c.Valid != "" {
+		validValues, err := findValues(c.Valid, vmi)
+		if err != nil {
+			return fmt.Sprintf("invalid valid path %v: %v", c.Valid, err.Error())
+		}
+		if len(validValues) == 0 {
+			return ""
+		}
+	}
+
+	values, err := findValues(c.Path, vmi)
+	if err != nil {
+		return fmt.Sprintf("invalid path %v: %v", c.Path, err.Error())
+	}
+
+	for _, value := range values {
+		if reason := c.evaluateValue(value); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+func (c *CommonTemplateValidation) evaluateValue(value interface{}) string {
+	path := strings.TrimPrefix(c.Path, jsonPathPrefix)
+
+	switch c.Rule {
+	case integerRule:
+		number, err := toInteger(value)
+		if err != nil {
+			return fmt.Sprintf("value %v of %v is not an integer: %v", value, path, err.Error())
+		}
+		if c.Min != 0 && number < int64(c.Min) {
+			return fmt.Sprintf("value %v of %v is lower than %v", value, path, c.Min)
+		}
+		if c.Max != 0 && number > int64(c.Max) {
+			return fmt.Sprintf("value %v of %v is greater than %v", value, path, c.Max)
+		}
+	case stringRule:
+		length := len(fmt.Sprint(value))
+		if length < c.MinLength {
+			return fmt.Sprintf("value %q of %v is shorter than %v", value, path, c.MinLength)
+		}
+		if c.MaxLength != 0 && length > c.MaxLength {
+			return fmt.Sprintf("value %q of %v is longer than %v", value, path, c.MaxLength)
+		}
+	case regexRule:
+		regex, err := regexp.Compile(c.Regex)
+		if err != nil {
+			return fmt.Sprintf("invalid regex %v: %v", c.Regex, err.Error())
+		}
+		if !regex.MatchString(fmt.Sprint(value)) {
+			return fmt.Sprintf("value %q of %v does not match %v", value, path, c.Regex)
+		}
+	case enumRule:
+		for _, allowedValue := range c.Values {
+			if fmt.Sprint(value) == allowedValue {
+				return ""
+			}
+		}
+		return fmt.Sprintf("value %q of %v is not one of %v", value, path, strings.Join(c.Values, ", "))
+	default:
+		return fmt.Sprintf("unknown rule %v: only %v|%v|%v|%v is supported", c.Rule, integerRule, stringRule, regexRule, enumRule)
+	}
+	return ""
+}
+
+func findValues(path string, data map[string]interface{}) ([]interface{}, error) {
+	if !strings.HasPrefix(path, jsonPathPrefix) {
+		return nil, fmt.Errorf("path should start with %v", jsonPathPrefix)
+	}
+
+	parser := jsonpath.New(path).AllowMissingKeys(true)
+	if err := parser.Parse("{" + strings.TrimPrefix(path, jsonPathPrefix) + "}"); err != nil {
+		return nil, err
+	}
+
+	results, err := parser.FindResults(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []interface{}
+	for _, result := range results {
+		for _, value := range result {
+			if !value.IsValid() || !value.CanInterface() {
+				continue
+			}
+			if v := value.Interface(); v != nil {
+				values = append(values, v)
+			}
+		}
+	}
+	return values, nil
+}
+
+// toInteger converts numbers and quantities (e.g. memory) to integers
+func toInteger(value interface{}) (int64, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return int64(v.Float()), nil
+	case reflect.String:
+		quantity, err := resource.ParseQuantity(v.String())
+		if err != nil {
+			return 0, err
+		}
+		return quantity.Value(), nil
+	}
+	return 0, fmt.Errorf("unsupported type %T", value)
+}
@@ -0,0 +1,126 @@
+package validations_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/create-vm/pkg/templates/validations"
+	. "github.com/kubevirt/kubevirt-tekton-tasks/modules/sharedtest/testconstants"
+	shtestobjects "github.com/kubevirt/kubevirt-tekton-tasks/modules/sharedtest/testobjects"
+)
+
+const (
+	memoryPath  = "jsonpath::.spec.domain.resources.requests.memory"
+	coresPath   = "jsonpath::.spec.domain.cpu.cores"
+	diskBusPath = "jsonpath::.spec.domain.devices.disks[*].disk.bus"
+	cdBusPath   = "jsonpath::.spec.domain.devices.disks[*].cdrom.bus"
+)
+
+var _ = Describe("Rules", func() {
+	var vm *kubevirtv1.VirtualMachine
+
+	BeforeEach(func() {
+		vm = shtestobjects.NewTestVM().
+			WithMemory("2Gi").
+			WithDisk(kubevirtv1.Disk{
+				Name: "rootdisk",
+				DiskDevice: kubevirtv1.DiskDevice{
+					Disk: &kubevirtv1.DiskTarget{Bus: Sata},
+				},
+			}).
+			Build()
+		vm.Spec.Template.ObjectMeta.Name = "test-vm"
+	})
+
+	DescribeTable("evaluates rules", func(validation validations.CommonTemplateValidation, expectedReason string) {
+		violations, err := validations.NewTemplateValidations([]validations.CommonTemplateValidation{validation}).Validate(vm)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		if expectedReason == "" {
+			Expect(violations).To(BeEmpty())
+		} else {
+			Expect(violations).To(HaveLen(1))
+			Expect(violations[0].Reason).To(Equal(expectedReason))
+		}
+	},
+		Entry("integer satisfied", validations.CommonTemplateValidation{Rule: "integer", Path: coresPath, Min: 1, Max: 2}, ""),
+		Entry("integer lower than min", validations.CommonTemplateValidation{Rule: "integer", Path: coresPath, Min: 2},
+			"value 1 of .spec.domain.cpu.cores is lower than 2"),
+		Entry("integer without limits", validations.CommonTemplateValidation{Rule: "integer", Path: coresPath}, ""),
+		Entry("integer quantity satisfied", validations.CommonTemplateValidation{Rule: "integer", Path: memoryPath, Min: 1073741824}, ""),
+		Entry("integer quantity greater than max", validations.CommonTemplateValidation{Rule: "integer", Path: memoryPath, Max: 1073741824},
+			"value 2Gi of .spec.domain.resources.requests.memory is greater than 1073741824"),
+		Entry("integer not a number", validations.CommonTemplateValidation{Rule: "integer", Path: diskBusPath, Min: 1},
+			"value sata of .spec.domain.devices.disks[*].disk.bus is not an integer: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'"),
+		Entry("string satisfied", validations.CommonTemplateValidation{Rule: "string", Path: "jsonpath::.metadata.name", MinLength: 1, MaxLength: 7}, ""),
+		Entry("string shorter than min length", validations.CommonTemplateValidation{Rule: "string", Path: "jsonpath::.metadata.name", MinLength: 8},
+			"value \"test-vm\" of .metadata.name is shorter than 8"),
+		Entry("string longer than max length", validations.CommonTemplateValidation{Rule: "string", Path: "jsonpath::.metadata.name", MaxLength: 6},
+			"value \"test-vm\" of .metadata.name is longer than 6"),
+		Entry("regex satisfied", validations.CommonTemplateValidation{Rule: "regex", Path: diskBusPath, Regex: "^(virtio|sata)$"}, ""),
+		Entry("regex not matched", validations.CommonTemplateValidation{Rule: "regex", Path: diskBusPath, Regex: "^virtio$"},
+			"value \"sata\" of .spec.domain.devices.disks[*].disk.bus does not match ^virtio$"),
+		Entry("invalid regex", validations.CommonTemplateValidation{Rule: "regex", Path: diskBusPath, Regex: "("},
+			"invalid regex (: error parsing regexp: missing closing ): `(`"),
+		Entry("enum satisfied", validations.CommonTemplateValidation{Rule: "enum", Path: diskBusPath, Values: []string{Virtio, Sata}}, ""),
+		Entry("enum not allowed value", validations.CommonTemplateValidation{Rule: "enum", Path: diskBusPath, Values: []string{Virtio, Scsi}},
+			"value \"sata\" of .spec.domain.devices.disks[*].disk.bus is not one of virtio, scsi"),
+		Entry("missing path is skipped", validations.CommonTemplateValidation{Rule: "enum", Path: cdBusPath, Values: []string{Sata}}, ""),
+		Entry("missing valid path is skipped", validations.CommonTemplateValidation{Rule: "enum", Path: diskBusPath, Valid: cdBusPath, Values: []string{Virtio}}, ""),
+		Entry("present valid path is evaluated", validations.CommonTemplateValidation{Rule: "enum", Path: diskBusPath, Valid: diskBusPath, Values: []string{Virtio}},
+			"value \"sata\" of .spec.domain.devices.disks[*].disk.bus is not one of virtio"),
+		Entry("invalid path", validations.CommonTemplateValidation{Rule: "enum", Path: ".spec.domain.devices.disks[*].disk.bus", Values: []string{Virtio}},
+			"invalid path .spec.domain.devices.disks[*].disk.bus: path should start with jsonpath::"),
+		Entry("unknown rule", validations.CommonTemplateValidation{Rule: "unknown", Path: diskBusPath},
+			"unknown rule unknown: only integer|string|regex|enum is supported"),
+	)
+
+	It("reports all violated rules", func() {
+		templateValidations := validations.NewTemplateValidations([]validations.CommonTemplateValidation{
+			{
+				Name:    "minimal-required-memory",
+				Rule:    "integer",
+				Path:    memoryPath,
+				Message: "This VM requires more memory.",
+				Min:     4294967296,
+			},
+			{
+				Name:        "virtio-bus",
+				Rule:        "enum",
+				Path:        diskBusPath,
+				Message:     "virtio disk bus type has better performance",
+				Values:      []string{Virtio},
+				JustWarning: true,
+			},
+			{
+				Name:    "disk-bus",
+				Rule:    "enum",
+				Path:    diskBusPath,
+				Message: "virtio, sata allowed only",
+				Values:  []string{Virtio, Sata},
+			},
+		})
+
+		violations, err := templateValidations.Validate(vm)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(violations).To(Equal([]validations.Violation{
+			{
+				Name:    "minimal-required-memory",
+				Message: "This VM requires more memory.",
+				Reason:  "value 2Gi of .spec.domain.resources.requests.memory is lower than 4294967296",
+			},
+			{
+				Name:        "virtio-bus",
+				Message:     "virtio disk bus type has better performance",
+				Reason:      "value \"sata\" of .spec.domain.devices.disks[*].disk.bus is not one of virtio",
+				JustWarning: true,
+			},
+		}))
+		Expect(violations[0].String()).To(Equal("minimal-required-memory: This VM requires more memory.: value 2Gi of .spec.domain.resources.requests.memory is lower than 4294967296"))
+	})
+
+	It("does not report violations without rules", func() {
+		Expect(validations.NewTemplateValidations(nil).Validate(vm)).To(BeEmpty())
+	})
+})
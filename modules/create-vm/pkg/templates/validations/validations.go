@@ -20,6 +20,7 @@ type CommonTemplateValidation struct {
 	Name        string   // Identifier of the rule. Must be unique among all the rules attached to a template
 	Rule        string   // Validation rule name. One of integer|string|regex|enum
 	Path        string   // jsonpath of the field whose value is going to be evaluated.
+	Valid       string   // jsonpath of the field which must be present for the rule to be evaluated.
 	Message     string   // User-friendly string message describing the failure, should the rule not be satisfied
 	Min         int      // For 'integer' rule
 	Max         int      // For 'integer' rule
@@ -16,6 +16,8 @@ const (
 	templateNameOptionName      = "template-name"
 	templateNamespaceOptionName = "template-namespace"
 	templateParamsOptionName    = "template-params"
+	dryRunOptionName            = "dry-run"
+	dvSourcesOptionName         = "dv-sources"
 )
 
 const templateParamSep = ":"
@@ -31,8 +33,10 @@ type CLIOptions struct {
 	OwnDataVolumes            []string          `arg:"--own-dvs" placeholder:"DV1 VOLUME_NAME:DV2 DV3" help:"Add DataVolumes to VM Volumes and add VM to DV ownerReferences. These DVs will be deleted once the created VM gets deleted. Replaces a particular volume if in VOLUME_NAME:DV_NAME format."`
 	PersistentVolumeClaims    []string          `arg:"--pvcs" placeholder:"PVC1 VOLUME_NAME:PVC2 PVC3" help:"Add PersistentVolumeClaims to VM Volumes. Replaces a particular volume if in PVC_NAME:DV_NAME format."`
 	OwnPersistentVolumeClaims []string          `arg:"--own-pvcs" placeholder:"PVC1  VOLUME_NAME:PVC2 PVC3" help:"Add PersistentVolumeClaims to VM Volumes and add VM to PVC ownerReferences. These PVCs will be deleted once the created VM gets deleted. Replaces a particular volume if in PVC_NAME:DV_NAME format."`
+	DataVolumeSources         string            `arg:"--dv-sources,env:DV_SOURCES" placeholder:"SOURCES" help:"YAML list of DataVolumes to be created from a source together with the VM. Each item has a volume name, source or sourceRef, size and storageClass. Replaces a particular volume if it exists (can be set by DV_SOURCES env variable)."`
 	StartVM                   string            `arg:"--start-vm,env:START_VM" help:"Start vm after creation"`
 	RunStrategy               string            `arg:"--run-strategy,env:RUN_STRATEGY" help:"Set run strategy to vm"`
+	DryRun                    string            `arg:"--dry-run,env:DRY_RUN" placeholder:"MODE" help:"Render the VM without creating it. One of: none|client|server"`
 	Output                    output.OutputType `arg:"-o" placeholder:"FORMAT" help:"Output format. One of: yaml|json"`
 	Debug                     bool              `arg:"--debug" help:"Sets DEBUG log level"`
 }
@@ -53,6 +57,15 @@ func (c *CLIOptions) GetOwnDVNames() []string {
 	return removeVolumePrefixes(c.OwnDataVolumes)
 }
 
+func (c *CLIOptions) GetDataVolumeSources() []DataVolumeSource {
+	result, err := parseDataVolumeSources(c.DataVolumeSources)
+
+	if err != nil {
+		panic(fmt.Errorf("init was not called: %v", err.Error()))
+	}
+	return result
+}
+
 func (c *CLIOptions) GetStartVMFlag() bool {
 	return c.StartVM == "true"
 }
@@ -61,6 +74,17 @@ func (c *CLIOptions) GetRunStrategy() string {
 	return c.RunStrategy
 }
 
+func (c *CLIOptions) GetDryRunMode() constants.DryRunMode {
+	if c.DryRun == "" {
+		return constants.NoneDryRunMode
+	}
+	return constants.DryRunMode(c.DryRun)
+}
+
+func (c *CLIOptions) IsDryRun() bool {
+	return c.GetDryRunMode() != constants.NoneDryRunMode
+}
+
 func (c *CLIOptions) GetPVCDiskNamesMap() map[string]string {
 	return getDiskNameMap(zutils.ConcatStringSlices(c.OwnPersistentVolumeClaims, c.PersistentVolumeClaims))
 }
@@ -125,6 +149,10 @@ func (c *CLIOptions) Init() error {
 		return zerrors.NewMissingRequiredError("invalid %v: %v", templateParamsOptionName, err.Error())
 	}
 
+	if err := c.assertValidDataVolumeSources(); err != nil {
+		return err
+	}
+
 	if err := c.resolveDefaultNamespacesAndManifests(); err != nil {
 		return err
 	}
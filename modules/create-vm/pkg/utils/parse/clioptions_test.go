@@ -10,6 +10,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"go.uber.org/zap/zapcore"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 )
 
 var (
@@ -43,6 +44,43 @@ var _ = Describe("CLIOptions", func() {
 			TemplateName: "test",
 			Output:       "incorrect-fmt",
 		}),
+		Entry("invalid dry run", "invalid option dry-run true, only none|client|server is allowed", &parse.CLIOptions{
+			TemplateName: "test",
+			DryRun:       "true",
+		}),
+		Entry("invalid dv sources", "could not read dv-sources: error unmarshaling JSON", &parse.CLIOptions{
+			TemplateName:      "test",
+			DataVolumeSources: "- name: disk1\n  unknown: field",
+		}),
+		Entry("dv source without name", "dv-sources: name should not be empty", &parse.CLIOptions{
+			TemplateName:      "test",
+			DataVolumeSources: "- sourceRef:\n    kind: DataSource\n    name: fedora",
+		}),
+		Entry("duplicate dv source", "dv-sources disk1: volume is already specified", &parse.CLIOptions{
+			TemplateName:      "test",
+			DataVolumeSources: "- name: disk1\n  sourceRef:\n    kind: DataSource\n    name: fedora\n- name: disk1\n  sourceRef:\n    kind: DataSource\n    name: rhel",
+		}),
+		Entry("dv source with volume used by dvs", "dv-sources disk1: volume is already specified", &parse.CLIOptions{
+			TemplateName:      "test",
+			OwnDataVolumes:    []string{"disk1:dv1"},
+			DataVolumeSources: "- name: disk1\n  sourceRef:\n    kind: DataSource\n    name: fedora",
+		}),
+		Entry("dv source without source", "dv-sources disk1: exactly one of source, sourceRef should be specified", &parse.CLIOptions{
+			TemplateName:      "test",
+			DataVolumeSources: "- name: disk1\n  size: 1Gi",
+		}),
+		Entry("dv source with source and sourceRef", "dv-sources disk1: exactly one of source, sourceRef should be specified", &parse.CLIOptions{
+			TemplateName:      "test",
+			DataVolumeSources: "- name: disk1\n  source:\n    blank: {}\n  sourceRef:\n    kind: DataSource\n    name: fedora",
+		}),
+		Entry("dv source without size", "dv-sources disk1: size should be specified unless a PVC or a DataSource is cloned", &parse.CLIOptions{
+			TemplateName:      "test",
+			DataVolumeSources: "- name: disk1\n  source:\n    blank: {}",
+		}),
+		Entry("dv source with invalid size", "dv-sources disk1: invalid size 1GB: quantities must match the regular expression", &parse.CLIOptions{
+			TemplateName:      "test",
+			DataVolumeSources: "- name: disk1\n  source:\n    blank: {}\n  size: 1GB",
+		}),
 		Entry("invalid template params 1", "invalid template-params: no key found before \"V1\"; pair should be in \"KEY:VAL\" format", &parse.CLIOptions{
 			TemplateName:   "test",
 			TemplateParams: []string{"V1", "K2=V2"},
@@ -80,6 +118,9 @@ var _ = Describe("CLIOptions", func() {
 			"GetCreationMode":            constants.TemplateCreationMode,
 			"GetStartVMFlag":             false,
 			"GetRunStrategy":             "",
+			"GetDryRunMode":              constants.NoneDryRunMode,
+			"IsDryRun":                   false,
+			"GetDataVolumeSources":       []parse.DataVolumeSource(nil),
 		}),
 		Entry("handles template cli arguments", &parse.CLIOptions{
 			TemplateName:              "test",
@@ -94,6 +135,8 @@ var _ = Describe("CLIOptions", func() {
 			Debug:                     true,
 			StartVM:                   "true",
 			RunStrategy:               "Always",
+			DryRun:                    "server",
+			DataVolumeSources:         "- name: rootdisk\n  source:\n    pvc:\n      name: fedora\n      namespace: os-images\n  storageClass: fast",
 		}, map[string]interface{}{
 			"GetTemplateNamespace":       defaultNS,
 			"GetVirtualMachineNamespace": defaultNS,
@@ -122,6 +165,20 @@ var _ = Describe("CLIOptions", func() {
 			"GetCreationMode": constants.TemplateCreationMode,
 			"GetStartVMFlag":  true,
 			"GetRunStrategy":  "Always",
+			"GetDryRunMode":   constants.ServerDryRunMode,
+			"IsDryRun":        true,
+			"GetDataVolumeSources": []parse.DataVolumeSource{
+				{
+					Name: "rootdisk",
+					Source: &cdiv1beta1.DataVolumeSource{
+						PVC: &cdiv1beta1.DataVolumeSourcePVC{
+							Name:      "fedora",
+							Namespace: "os-images",
+						},
+					},
+					StorageClass: "fast",
+				},
+			},
 		}),
 		Entry("handles vm cli arguments", &parse.CLIOptions{
 			VirtualMachineManifest:    testVMManifest,
@@ -134,6 +191,7 @@ var _ = Describe("CLIOptions", func() {
 			Debug:                     true,
 			StartVM:                   "false",
 			RunStrategy:               "Always",
+			DryRun:                    "client",
 		}, map[string]interface{}{
 			"GetTemplateNamespace":       "",
 			"GetVirtualMachineNamespace": defaultNS,
@@ -157,6 +215,8 @@ var _ = Describe("CLIOptions", func() {
 			"GetCreationMode":   constants.VMManifestCreationMode,
 			"GetStartVMFlag":    false,
 			"GetRunStrategy":    "Always",
+			"GetDryRunMode":     constants.ClientDryRunMode,
+			"IsDryRun":          true,
 		}),
 		Entry("handles trim", &parse.CLIOptions{
 			TemplateName:              "test",
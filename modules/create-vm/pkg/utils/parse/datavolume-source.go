@@ -0,0 +1,33 @@
+package parse
+
+import (
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+// DataVolumeSource is a DataVolume to be created from a source as a dataVolumeTemplate of the VM
+type DataVolumeSource struct {
+	// Name of the VM volume. The DataVolume is named VM_NAME-NAME.
+	Name string `json:"name"`
+	// Source of the DataVolume, e.g. http, registry or pvc. Only one of Source and SourceRef can be set.
+	Source *cdiv1beta1.DataVolumeSource `json:"source,omitempty"`
+	// SourceRef references a DataSource the DataVolume is cloned from.
+	SourceRef *cdiv1beta1.DataVolumeSourceRef `json:"sourceRef,omitempty"`
+	// Size of the DataVolume. Can be omitted when the size can be inferred from the cloned PVC or DataSource.
+	Size string `json:"size,omitempty"`
+	// StorageClass of the DataVolume. The default storage class is used if empty.
+	StorageClass string `json:"storageClass,omitempty"`
+}
+
+func parseDataVolumeSources(manifest string) ([]DataVolumeSource, error) {
+	var result []DataVolumeSource
+
+	if manifest == "" {
+		return result, nil
+	}
+
+	if err := yaml.UnmarshalStrict([]byte(manifest), &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
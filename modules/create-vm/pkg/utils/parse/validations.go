@@ -7,6 +7,7 @@ import (
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/env"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/output"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zerrors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 	"sigs.k8s.io/yaml"
 )
@@ -35,6 +36,52 @@ func (c *CLIOptions) assertValidTypes() error {
 	if !output.IsOutputType(string(c.Output)) {
 		return zerrors.NewMissingRequiredError("%v is not a valid output type", c.Output)
 	}
+
+	switch c.GetDryRunMode() {
+	case constants.NoneDryRunMode, constants.ClientDryRunMode, constants.ServerDryRunMode:
+	default:
+		return zerrors.NewMissingRequiredError("invalid option %v %v, only %v|%v|%v is allowed", dryRunOptionName, c.DryRun,
+			constants.NoneDryRunMode, constants.ClientDryRunMode, constants.ServerDryRunMode)
+	}
+	return nil
+}
+
+func (c *CLIOptions) assertValidDataVolumeSources() error {
+	dataVolumeSources, err := parseDataVolumeSources(c.DataVolumeSources)
+	if err != nil {
+		return zerrors.NewMissingRequiredError("could not read %v: %v", dvSourcesOptionName, err.Error())
+	}
+
+	volumeNames := make(map[string]bool)
+	for volumeName := range c.GetPVCDiskNamesMap() {
+		volumeNames[volumeName] = true
+	}
+	for volumeName := range c.GetDVDiskNamesMap() {
+		volumeNames[volumeName] = true
+	}
+
+	for _, dataVolumeSource := range dataVolumeSources {
+		if dataVolumeSource.Name == "" {
+			return zerrors.NewMissingRequiredError("%v: name should not be empty", dvSourcesOptionName)
+		}
+		if volumeNames[dataVolumeSource.Name] {
+			return zerrors.NewMissingRequiredError("%v %v: volume is already specified", dvSourcesOptionName, dataVolumeSource.Name)
+		}
+		volumeNames[dataVolumeSource.Name] = true
+
+		if (dataVolumeSource.Source == nil) == (dataVolumeSource.SourceRef == nil) {
+			return zerrors.NewMissingRequiredError("%v %v: exactly one of source, sourceRef should be specified", dvSourcesOptionName, dataVolumeSource.Name)
+		}
+
+		if dataVolumeSource.Size == "" {
+			if dataVolumeSource.Source != nil && dataVolumeSource.Source.PVC == nil {
+				return zerrors.NewMissingRequiredError("%v %v: size should be specified unless a PVC or a DataSource is cloned", dvSourcesOptionName, dataVolumeSource.Name)
+			}
+		} else if _, err := resource.ParseQuantity(dataVolumeSource.Size); err != nil {
+			return zerrors.NewMissingRequiredError("%v %v: invalid size %v: %v", dvSourcesOptionName, dataVolumeSource.Name, dataVolumeSource.Size, err.Error())
+		}
+	}
+
 	return nil
 }
 
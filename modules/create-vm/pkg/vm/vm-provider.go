@@ -1,6 +1,9 @@
 package vm
 
 import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 	kubevirtcliv1 "kubevirt.io/client-go/kubecli"
 )
@@ -11,6 +14,7 @@ type virtualMachineProvider struct {
 
 type VirtualMachineProvider interface {
 	Create(namespace string, vm *kubevirtv1.VirtualMachine) (*kubevirtv1.VirtualMachine, error)
+	DryRunCreate(namespace string, vm *kubevirtv1.VirtualMachine) (*kubevirtv1.VirtualMachine, error)
 	Start(namespace, name string) error
 }
 
@@ -24,6 +28,22 @@ func (v *virtualMachineProvider) Create(namespace string, vm *kubevirtv1.Virtual
 	return v.client.VirtualMachine(namespace).Create(vm)
 }
 
+// DryRunCreate submits the VM to the server without persisting it and returns the VM as it would be created
+func (v *virtualMachineProvider) DryRunCreate(namespace string, vm *kubevirtv1.VirtualMachine) (*kubevirtv1.VirtualMachine, error) {
+	newVM := &kubevirtv1.VirtualMachine{}
+	err := v.client.RestClient().Post().
+		Resource("virtualmachines").
+		Namespace(namespace).
+		VersionedParams(&metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}}, metav1.ParameterCodec).
+		Body(vm).
+		Do(context.Background()).
+		Into(newVM)
+
+	newVM.SetGroupVersionKind(kubevirtv1.VirtualMachineGroupVersionKind)
+
+	return newVM, err
+}
+
 func (v *virtualMachineProvider) Start(namespace, name string) error {
 	return v.client.VirtualMachine(namespace).Start(name, &kubevirtv1.StartOptions{})
 }
@@ -9,8 +9,10 @@ import (
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zconstants"
 	templatev1 "github.com/openshift/api/template/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubevirtv1 "kubevirt.io/api/core/v1"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 )
 
 func AddMetadata(vm *kubevirtv1.VirtualMachine, template *templatev1.Template) {
@@ -138,6 +140,83 @@ func AddVolumes(vm *kubevirtv1.VirtualMachine, templateValidations *validations.
 			volume.DataVolume.Name = dvName
 		}
 	}
+
+	for _, dataVolumeSource := range cliParams.GetDataVolumeSources() {
+		volumeName := dataVolumeSource.Name
+		dvName := vm.GetName() + "-" + volumeName
+
+		ensureDisk(volumeName)
+		volume := ensureVolume(volumeName)
+
+		replacedDVName := ""
+		if volume.DataVolume == nil {
+			volume.VolumeSource = kubevirtv1.VolumeSource{
+				DataVolume: &kubevirtv1.DataVolumeSource{Name: dvName},
+			}
+		} else {
+			if volume.DataVolume.Name != dvName {
+				replacedDVName = volume.DataVolume.Name
+			}
+			volume.DataVolume.Name = dvName
+		}
+
+		// the dataVolumeTemplate of the replaced DataVolume (e.g. ${NAME} of common templates) would be created without being used
+		if replacedDVName != "" && isDataVolumeReferenced(vm, replacedDVName) {
+			replacedDVName = ""
+		}
+		addDataVolumeTemplate(vm, dvName, replacedDVName, dataVolumeSource)
+	}
+}
+
+func isDataVolumeReferenced(vm *kubevirtv1.VirtualMachine, dvName string) bool {
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.DataVolume != nil && volume.DataVolume.Name == dvName {
+			return true
+		}
+	}
+	return false
+}
+
+// addDataVolumeTemplate adds a dataVolumeTemplate or replaces the one with the same name or with the replacedName
+func addDataVolumeTemplate(vm *kubevirtv1.VirtualMachine, name, replacedName string, dataVolumeSource parse.DataVolumeSource) {
+	storage := &cdiv1beta1.StorageSpec{}
+	if dataVolumeSource.Size != "" {
+		storage.Resources.Requests = v1.ResourceList{
+			v1.ResourceStorage: resource.MustParse(dataVolumeSource.Size),
+		}
+	}
+	if dataVolumeSource.StorageClass != "" {
+		storageClass := dataVolumeSource.StorageClass
+		storage.StorageClassName = &storageClass
+	}
+
+	dataVolumeTemplate := kubevirtv1.DataVolumeTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: cdiv1beta1.DataVolumeSpec{
+			Source:    dataVolumeSource.Source,
+			SourceRef: dataVolumeSource.SourceRef,
+			Storage:   storage,
+		},
+	}
+
+	var dataVolumeTemplates []kubevirtv1.DataVolumeTemplateSpec
+	added := false
+	for _, existingTemplate := range vm.Spec.DataVolumeTemplates {
+		if existingTemplate.Name == name || (replacedName != "" && existingTemplate.Name == replacedName) {
+			if !added {
+				dataVolumeTemplates = append(dataVolumeTemplates, dataVolumeTemplate)
+				added = true
+			}
+			continue
+		}
+		dataVolumeTemplates = append(dataVolumeTemplates, existingTemplate)
+	}
+	if !added {
+		dataVolumeTemplates = append(dataVolumeTemplates, dataVolumeTemplate)
+	}
+	vm.Spec.DataVolumeTemplates = dataVolumeTemplates
 }
 
 func AsVMOwnerReference(vm *kubevirtv1.VirtualMachine) metav1.OwnerReference {
@@ -7,8 +7,10 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubevirtv1 "kubevirt.io/api/core/v1"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/create-vm/pkg/templates/validations"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/create-vm/pkg/utils/parse"
@@ -196,6 +198,179 @@ var _ = Describe("VM", func() {
 				},
 			))
 		})
+
+		It("adds DataVolumes from sources", func() {
+			vm.Spec.Template.Spec.Volumes = append(vm.Spec.Template.Spec.Volumes,
+				kubevirtv1.Volume{
+					Name: "rootdisk",
+					VolumeSource: kubevirtv1.VolumeSource{
+						ContainerDisk: &kubevirtv1.ContainerDiskSource{Image: "quay.io/containerdisks/fedora"},
+					},
+				},
+			)
+			vm.Spec.DataVolumeTemplates = append(vm.Spec.DataVolumeTemplates, kubevirtv1.DataVolumeTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Name: vm.Name + "-datadisk"},
+			})
+
+			cliOptions.OwnDataVolumes = nil
+			cliOptions.DataVolumes = nil
+			cliOptions.OwnPersistentVolumeClaims = nil
+			cliOptions.PersistentVolumeClaims = nil
+			cliOptions.DataVolumeSources = `
+- name: rootdisk
+  source:
+    http:
+      url: https://example.com/disk.qcow2
+  size: 10Gi
+  storageClass: fast
+- name: datadisk
+  sourceRef:
+    kind: DataSource
+    name: fedora
+    namespace: os-images
+`
+			Expect(cliOptions.Init()).Should(Succeed())
+
+			vm2.AddVolumes(vm, emptyValidations, cliOptions)
+
+			storageClass := "fast"
+			sourceRefNamespace := "os-images"
+			Expect(vm.Spec.DataVolumeTemplates).To(Equal([]kubevirtv1.DataVolumeTemplateSpec{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: vm.Name + "-datadisk"},
+					Spec: cdiv1beta1.DataVolumeSpec{
+						SourceRef: &cdiv1beta1.DataVolumeSourceRef{
+							Kind:      "DataSource",
+							Name:      "fedora",
+							Namespace: &sourceRefNamespace,
+						},
+						Storage: &cdiv1beta1.StorageSpec{},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: vm.Name + "-rootdisk"},
+					Spec: cdiv1beta1.DataVolumeSpec{
+						Source: &cdiv1beta1.DataVolumeSource{
+							HTTP: &cdiv1beta1.DataVolumeSourceHTTP{URL: "https://example.com/disk.qcow2"},
+						},
+						Storage: &cdiv1beta1.StorageSpec{
+							Resources: v1.ResourceRequirements{
+								Requests: v1.ResourceList{
+									v1.ResourceStorage: resource.MustParse("10Gi"),
+								},
+							},
+							StorageClassName: &storageClass,
+						},
+					},
+				},
+			}))
+
+			Expect(vm.Spec.Template.Spec.Volumes).To(Equal([]kubevirtv1.Volume{
+				{
+					Name: "rootdisk",
+					VolumeSource: kubevirtv1.VolumeSource{
+						DataVolume: &kubevirtv1.DataVolumeSource{Name: vm.Name + "-rootdisk"},
+					},
+				},
+				{
+					Name: "datadisk",
+					VolumeSource: kubevirtv1.VolumeSource{
+						DataVolume: &kubevirtv1.DataVolumeSource{Name: vm.Name + "-datadisk"},
+					},
+				},
+			}))
+
+			Expect(vm.Spec.Template.Spec.Domain.Devices.Disks).To(Equal([]kubevirtv1.Disk{
+				{
+					Name: "rootdisk",
+					DiskDevice: kubevirtv1.DiskDevice{
+						Disk: &kubevirtv1.DiskTarget{Bus: Virtio},
+					},
+				},
+				{
+					Name: "datadisk",
+					DiskDevice: kubevirtv1.DiskDevice{
+						Disk: &kubevirtv1.DiskTarget{Bus: Virtio},
+					},
+				},
+			}))
+		})
+	})
+
+	Describe("Adds DataVolumes from sources replacing dataVolumeTemplates", func() {
+		var cliOptions *parse.CLIOptions
+		sourceRefNamespace := "os-images"
+
+		newDataVolumeTemplate := func(name, dataSourceName string) kubevirtv1.DataVolumeTemplateSpec {
+			return kubevirtv1.DataVolumeTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec: cdiv1beta1.DataVolumeSpec{
+					SourceRef: &cdiv1beta1.DataVolumeSourceRef{
+						Kind:      "DataSource",
+						Name:      dataSourceName,
+						Namespace: &sourceRefNamespace,
+					},
+					Storage: &cdiv1beta1.StorageSpec{},
+				},
+			}
+		}
+
+		newDataVolume := func(volumeName, dvName string) kubevirtv1.Volume {
+			return kubevirtv1.Volume{
+				Name: volumeName,
+				VolumeSource: kubevirtv1.VolumeSource{
+					DataVolume: &kubevirtv1.DataVolumeSource{Name: dvName},
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			vm = shtestobjects.NewTestVM().Build()
+			// common templates name the root disk DataVolume after the VM
+			vm.Spec.Template.Spec.Volumes = append(vm.Spec.Template.Spec.Volumes, newDataVolume("rootdisk", vm.Name))
+			vm.Spec.DataVolumeTemplates = []kubevirtv1.DataVolumeTemplateSpec{
+				newDataVolumeTemplate(vm.Name, "fedora"),
+				newDataVolumeTemplate("scratch", "scratch"),
+			}
+
+			cliOptions = &parse.CLIOptions{
+				TemplateName:            "test",
+				TemplateNamespace:       "default",
+				VirtualMachineNamespace: "default",
+				DataVolumeSources: `
+- name: rootdisk
+  sourceRef:
+    kind: DataSource
+    name: rhel9
+    namespace: os-images
+`,
+			}
+			Expect(cliOptions.Init()).Should(Succeed())
+		})
+
+		It("replaces the dataVolumeTemplate of the replaced volume", func() {
+			vm2.AddVolumes(vm, validations.NewTemplateValidations(nil), cliOptions)
+
+			Expect(vm.Spec.DataVolumeTemplates).To(Equal([]kubevirtv1.DataVolumeTemplateSpec{
+				newDataVolumeTemplate(vm.Name+"-rootdisk", "rhel9"),
+				newDataVolumeTemplate("scratch", "scratch"),
+			}))
+			Expect(vm.Spec.Template.Spec.Volumes).To(Equal([]kubevirtv1.Volume{
+				newDataVolume("rootdisk", vm.Name+"-rootdisk"),
+			}))
+		})
+
+		It("keeps the dataVolumeTemplate referenced by another volume", func() {
+			vm.Spec.Template.Spec.Volumes = append(vm.Spec.Template.Spec.Volumes, newDataVolume("otherdisk", vm.Name))
+
+			vm2.AddVolumes(vm, validations.NewTemplateValidations(nil), cliOptions)
+
+			Expect(vm.Spec.DataVolumeTemplates).To(Equal([]kubevirtv1.DataVolumeTemplateSpec{
+				newDataVolumeTemplate(vm.Name, "fedora"),
+				newDataVolumeTemplate("scratch", "scratch"),
+				newDataVolumeTemplate(vm.Name+"-rootdisk", "rhel9"),
+			}))
+		})
 	})
 
 	It("Adds correct metadata from template", func() {
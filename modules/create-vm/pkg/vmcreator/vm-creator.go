@@ -2,6 +2,7 @@ package vmcreator
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/create-vm/pkg/constants"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/create-vm/pkg/datavolume"
@@ -94,6 +95,10 @@ func (v *VMCreator) createVMFromManifest() (*kubevirtv1.VirtualMachine, error) {
 	vm.Namespace = v.targetNamespace
 	virtualMachine.AddMetadata(&vm, nil)
 
+	if err := assertVMNameForDataVolumeSources(&vm, v.cliOptions); err != nil {
+		return nil, err
+	}
+
 	templateValidations := validations.NewTemplateValidations(nil) // fallback to defaults
 	virtualMachine.AddVolumes(&vm, templateValidations, v.cliOptions)
 
@@ -103,8 +108,7 @@ func (v *VMCreator) createVMFromManifest() (*kubevirtv1.VirtualMachine, error) {
 		vm.Spec.RunStrategy = &runStrategy
 	}
 
-	log.Logger().Debug("creating VM", zap.Reflect("vm", vm))
-	return v.virtualMachineProvider.Create(v.targetNamespace, &vm)
+	return v.createVM(&vm)
 }
 
 func (v *VMCreator) createVMFromTemplate() (*kubevirtv1.VirtualMachine, error) {
@@ -136,6 +140,10 @@ func (v *VMCreator) createVMFromTemplate() (*kubevirtv1.VirtualMachine, error) {
 	vm.Namespace = v.targetNamespace
 
 	virtualMachine.AddMetadata(vm, processedTemplate)
+
+	if err := assertVMNameForDataVolumeSources(vm, v.cliOptions); err != nil {
+		return nil, err
+	}
 	virtualMachine.AddVolumes(vm, templateValidations, v.cliOptions)
 
 	runStrategy := kubevirtv1.VirtualMachineRunStrategy(v.cliOptions.GetRunStrategy())
@@ -144,6 +152,54 @@ func (v *VMCreator) createVMFromTemplate() (*kubevirtv1.VirtualMachine, error) {
 		vm.Spec.RunStrategy = &runStrategy
 	}
 
+	if err := assertTemplateValidations(vm, templateValidations); err != nil {
+		return nil, err
+	}
+
+	return v.createVM(vm)
+}
+
+// assertVMNameForDataVolumeSources fails if the DataVolumes created from sources can not be named after the VM
+func assertVMNameForDataVolumeSources(vm *kubevirtv1.VirtualMachine, cliOptions *parse.CLIOptions) error {
+	if vm.GetName() == "" && len(cliOptions.GetDataVolumeSources()) > 0 {
+		return zerrors.NewSoftError("VM name is required to name DataVolumes created from dv-sources")
+	}
+	return nil
+}
+
+// assertTemplateValidations logs the violated validations which are just warnings and fails on the rest of them
+func assertTemplateValidations(vm *kubevirtv1.VirtualMachine, templateValidations *validations.TemplateValidations) error {
+	violations, err := templateValidations.Validate(vm)
+	if err != nil {
+		return fmt.Errorf("could not evaluate template validations: %v", err.Error())
+	}
+
+	var violatedValidations []string
+	for _, violation := range violations {
+		if violation.JustWarning {
+			log.Logger().Warn("VM does not satisfy template validation", zap.Stringer("validation", violation))
+			continue
+		}
+		violatedValidations = append(violatedValidations, violation.String())
+	}
+
+	if len(violatedValidations) > 0 {
+		return zerrors.NewSoftError("VM does not satisfy template validations:\n%v", strings.Join(violatedValidations, "\n"))
+	}
+	return nil
+}
+
+func (v *VMCreator) createVM(vm *kubevirtv1.VirtualMachine) (*kubevirtv1.VirtualMachine, error) {
+	switch v.cliOptions.GetDryRunMode() {
+	case constants.ClientDryRunMode:
+		log.Logger().Debug("rendered VM without creating it", zap.Reflect("vm", vm))
+		vm.SetGroupVersionKind(kubevirtv1.VirtualMachineGroupVersionKind)
+		return vm, nil
+	case constants.ServerDryRunMode:
+		log.Logger().Debug("creating VM in server dry run mode", zap.Reflect("vm", vm))
+		return v.virtualMachineProvider.DryRunCreate(v.targetNamespace, vm)
+	}
+
 	log.Logger().Debug("creating VM", zap.Reflect("vm", vm))
 	return v.virtualMachineProvider.Create(v.targetNamespace, vm)
 }
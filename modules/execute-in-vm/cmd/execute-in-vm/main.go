@@ -1,9 +1,13 @@
 package main
 
 import (
+	"fmt"
+	"strings"
+
 	goarg "github.com/alexflint/go-arg"
 	. "github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execute"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/report"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils"
 	log "github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/parse"
@@ -28,84 +32,269 @@ func main() {
 		exit.ExitOrDieFromError(InvalidArguments, err)
 	}
 
+	executionReport := report.NewReport()
+	exitWithReport := func(code int, err error) {
+		writeReport(cliOptions, executionReport, code, err)
+		exit.ExitOrDieFromError(code, err)
+	}
+
+	if cliOptions.HasMultipleVirtualMachines() {
+		runInMultipleVMs(cliOptions, executionReport, exitWithReport)
+		writeReport(cliOptions, executionReport, 0, nil)
+		return
+	}
+
+	vmReport := executionReport.AddVirtualMachine(cliOptions.VirtualMachineName, cliOptions.GetVirtualMachineNamespace())
+
 	executor, executorErr := execute.NewExecutor(cliOptions, ConnectionSecretPath)
 	if executorErr != nil {
-		exit.ExitOrDieFromError(ExecutorInitialization, executorErr)
+		exitWithReport(ExecutorInitialization, executorErr)
+	}
+	executor.SetReport(vmReport)
+
+	multiError, exitError := run(cliOptions, executor, vmReport, true)
+
+	if !multiError.IsEmpty() {
+		if exitError != nil {
+			multiError.Add("command exit", *exitError)
+		}
+		log.Logger().Debug("finished", zap.String("errMsg", multiError.Error()))
+		exitWithReport(ExecutorActionsFailed, multiError)
+	}
+
+	if exitError != nil {
+		log.Logger().Debug("finished", zap.Reflect("err", exitError))
+		exitWithReport(exitError.Code, exitError)
 	}
 
+	writeReport(cliOptions, executionReport, 0, nil)
+}
+
+// writeReport writes the report if requested. Failures are only logged to keep the exit code of the task.
+func writeReport(cliOptions *parse.CLIOptions, executionReport *report.Report, exitCode int, err error) {
+	reportFile := cliOptions.GetReportFile()
+	if reportFile == "" {
+		return
+	}
+
+	executionReport.Finish(exitCode, err)
+	if err := executionReport.Write(reportFile); err != nil {
+		log.Logger().Warn("could not write report", zap.String("file", reportFile), zap.Error(err))
+	}
+}
+
+// run executes the actions in a single VM
+func run(cliOptions *parse.CLIOptions, executor *execute.Executor, vmReport *report.VirtualMachineReport, recordResults bool) (*zerrors.MultiError, *exit.Exit) {
 	multiError := zerrors.NewMultiError()
 	var exitError *exit.Exit
 
+	addError := func(name string, err error) {
+		multiError.Add(name, err)
+		vmReport.AddError(name, err)
+	}
+
 	registerError := func(name string, err error) {
 		if err != nil {
-			if exitErr, ok := err.(exit.Exit); ok {
-				exitError = &exitErr
-			} else if err == wait.ErrWaitTimeout {
-				exitError = &exit.Exit{
-					Code: CommandTimeout,
-					Msg:  "command timed out",
-					Soft: true,
-				}
+			if exitErr, ok := execute.GetExitError(err); ok {
+				exitError = exitErr
 			} else {
-				multiError.Add(name, err)
+				addError(name, err)
 			}
 		}
 
 	}
 
-	if cliOptions.GetScript() != "" {
+	if cliOptions.HasRemoteActions() {
 		runWithTimeout := utils.WithTimeout(cliOptions.GetScriptTimeout())
 
 		runWithTimeout(func(timeout time.Duration, finished bool) {
 			if multiError.IsEmpty() && !finished {
-				err := executor.EnsureVMRunning(timeout)
+				err := vmReport.RunPhase("EnsureVMRunning", func() error {
+					return executor.EnsureVMRunning(timeout)
+				})
 				registerError("EnsureVMRunning", err)
 			}
 		})
 
 		runWithTimeout(func(timeout time.Duration, finished bool) {
 			if multiError.IsEmpty() && !finished {
-				err := executor.SetupConnection(timeout)
+				err := vmReport.RunPhase("SetupConnection", func() error {
+					return executor.SetupConnection(timeout)
+				})
 				registerError("SetupConnection", err)
 			}
 		})
 
-		runWithTimeout(func(timeout time.Duration, finished bool) {
-			if multiError.IsEmpty() {
-				if !finished {
-					err := executor.RemoteExecute(timeout)
-					registerError("RemoteExecute", err)
-				} else {
-					registerError("RemoteExecute", wait.ErrWaitTimeout)
+		if cliOptions.ShouldTakeSnapshot() {
+			runWithTimeout(func(timeout time.Duration, finished bool) {
+				if multiError.IsEmpty() && exitError == nil {
+					if !finished {
+						err := vmReport.RunPhase("TakeSnapshot", func() error {
+							return executor.TakeSnapshot(timeout)
+						})
+						registerError("TakeSnapshot", err)
+					} else {
+						registerError("TakeSnapshot", wait.ErrWaitTimeout)
+					}
+				}
+			})
+		}
+
+		if len(cliOptions.GetUploads()) > 0 {
+			runWithTimeout(func(timeout time.Duration, finished bool) {
+				if multiError.IsEmpty() && exitError == nil {
+					if !finished {
+						err := vmReport.RunPhase("UploadFiles", func() error {
+							return executor.UploadFiles(timeout)
+						})
+						registerError("UploadFiles", err)
+					} else {
+						registerError("UploadFiles", wait.ErrWaitTimeout)
+					}
+				}
+			})
+		}
+
+		if cliOptions.GetScript() != "" || cliOptions.HasPlaybook() {
+			runWithTimeout(func(timeout time.Duration, finished bool) {
+				if multiError.IsEmpty() && exitError == nil {
+					if !finished {
+						err := vmReport.RunPhase("RemoteExecute", func() error {
+							return executor.RemoteExecute(timeout)
+						})
+						registerError("RemoteExecute", err)
+					} else {
+						registerError("RemoteExecute", wait.ErrWaitTimeout)
+					}
+
+				}
+			})
+		}
+
+		// download files even if the script failed, e.g. to retrieve test reports
+		if len(cliOptions.GetDownloads()) > 0 {
+			runWithTimeout(func(timeout time.Duration, finished bool) {
+				if multiError.IsEmpty() && (exitError == nil || exitError.Code != CommandTimeout) {
+					if !finished {
+						err := vmReport.RunPhase("DownloadFiles", func() error {
+							return executor.DownloadFiles(timeout)
+						})
+						registerError("DownloadFiles", err)
+					} else {
+						registerError("DownloadFiles", wait.ErrWaitTimeout)
+					}
 				}
+			})
+		}
 
+		if recordResults {
+			if err := executor.RecordResults(); err != nil {
+				addError("RecordResults", err)
+			}
+		}
+
+		if cliOptions.ShouldTakeSnapshot() {
+			failed := !multiError.IsEmpty() || (exitError != nil && exitError.Code != 0)
+			shouldRestore := cliOptions.GetRestoreSnapshotPolicy() == AlwaysRestoreSnapshot || failed
+
+			// restoring a VM which is going to be deleted is pointless
+			if executor.HasSnapshot() && shouldRestore && !cliOptions.ShouldDelete() {
+				if err := vmReport.RunPhase("RestoreSnapshot", func() error {
+					return executor.RestoreSnapshot(!cliOptions.ShouldStop(), RestoreSnapshotTimeout)
+				}); err != nil {
+					addError("RestoreSnapshot", err)
+				}
 			}
-		})
 
+			if err := vmReport.RunPhase("DeleteSnapshot", executor.DeleteSnapshot); err != nil {
+				addError("DeleteSnapshot", err)
+			}
+		}
 	}
 
 	if cliOptions.ShouldStop() {
-		if err := executor.EnsureVMStopped(); err != nil {
-			multiError.Add("VM Stop", err)
+		if err := vmReport.RunPhase("VM Stop", executor.EnsureVMStopped); err != nil {
+			addError("VM Stop", err)
 		}
 	}
 
 	if cliOptions.ShouldDelete() {
-		if err := executor.EnsureVMDeleted(); err != nil {
-			multiError.Add("VM Delete", err)
+		if err := vmReport.RunPhase("VM Delete", executor.EnsureVMDeleted); err != nil {
+			addError("VM Delete", err)
 		}
 	}
 
-	if !multiError.IsEmpty() {
-		if exitError != nil {
-			multiError.Add("command exit", *exitError)
+	if exitError != nil {
+		vmReport.SetExitCode(exitError.Code)
+	}
+
+	return multiError, exitError
+}
+
+// runInMultipleVMs executes the actions in each selected VM and records which VMs passed or failed
+func runInMultipleVMs(cliOptions *parse.CLIOptions, executionReport *report.Report, exitWithReport func(code int, err error)) {
+	vmNames, err := execute.GetVirtualMachineNames(cliOptions)
+	if err != nil {
+		exitWithReport(ExecutorInitialization, err)
+	}
+	if len(vmNames) == 0 {
+		exitWithReport(ExecutorInitialization, zerrors.NewMissingRequiredError("no VMs match the selector %v", cliOptions.GetVirtualMachineSelector()))
+	}
+
+	vmReports := map[string]*report.VirtualMachineReport{}
+	for _, vmName := range vmNames {
+		vmReports[vmName] = executionReport.AddVirtualMachine(vmName, cliOptions.GetVirtualMachineNamespace())
+	}
+
+	errs := execute.RunConcurrently(vmNames, cliOptions.GetConcurrency(), func(vmName string) error {
+		vmOptions := cliOptions.ForVirtualMachine(vmName)
+		vmReport := vmReports[vmName]
+
+		executor, err := execute.NewExecutor(vmOptions, ConnectionSecretPath)
+		if err != nil {
+			vmReport.AddError("NewExecutor", err)
+			return err
 		}
-		log.Logger().Debug("finished", zap.String("errMsg", multiError.Error()))
-		exit.ExitOrDieFromError(ExecutorActionsFailed, multiError)
+		executor.SetOutputPrefix(fmt.Sprintf("[%v] ", vmName))
+		executor.SetReport(vmReport)
+
+		multiError, exitError := run(vmOptions, executor, vmReport, false)
+		if !multiError.IsEmpty() {
+			if exitError != nil {
+				multiError.Add("command exit", *exitError)
+			}
+			return multiError
+		}
+		if exitError != nil && exitError.Code != 0 {
+			if exitError.Msg != "" {
+				return *exitError
+			}
+			return zerrors.NewSoftError("command exited with code %v", exitError.Code)
+		}
+		return nil
+	})
+
+	multiError := zerrors.NewMultiError()
+	var passedVMNames, failedVMNames []string
+	for idx, vmName := range vmNames {
+		if errs[idx] == nil {
+			passedVMNames = append(passedVMNames, vmName)
+			continue
+		}
+		failedVMNames = append(failedVMNames, vmName)
+		vmErr := fmt.Errorf("%v: %v", vmName, strings.TrimSpace(errs[idx].Error()))
+		if zerrors.IsErrorSoft(errs[idx]) {
+			vmErr = zerrors.NewSoftError("%v: %v", vmName, strings.TrimSpace(errs[idx].Error()))
+		}
+		multiError.Add(vmName, vmErr)
 	}
 
-	if exitError != nil {
-		log.Logger().Debug("finished", zap.Reflect("err", exitError))
-		exit.ExitOrDieFromError(exitError.Code, exitError)
+	if err := execute.RecordFleetResults(passedVMNames, failedVMNames); err != nil {
+		multiError.Add("RecordResults", err)
+	}
+
+	if !multiError.IsEmpty() {
+		log.Logger().Debug("finished", zap.Strings("failed", failedVMNames), zap.String("errMsg", multiError.Error()))
+		exitWithReport(ExecutorActionsFailed, multiError)
 	}
 }
@@ -11,11 +11,83 @@ const (
 	CommandTimeout         = -4
 )
 
+// Result names
+const (
+	UploadChecksumsResultName   = "uploadChecksums"
+	DownloadChecksumsResultName = "downloadChecksums"
+	StdoutResultName            = "stdout"
+	StderrResultName            = "stderr"
+	ExitCodeResultName          = "exitCode"
+	AttemptsResultName          = "attempts"
+	PassedVMsResultName         = "passedVMs"
+	FailedVMsResultName         = "failedVMs"
+	PlaybookTasksResultName     = "playbookTasks"
+)
+
+// OutputResultMaxSize limits the stdout and stderr results, so they fit into the size limit of all task results
+const OutputResultMaxSize = 1024
+
 const PollVMIInterval = 3 * time.Second
 const PollValidConnectionInterval = 3 * time.Second
-const CheckSSHConnectionTimeout = 3 * time.Second
+const CheckConnectionTimeout = 3 * time.Second
 const PollVMtoDeleteInterval = 1 * time.Second
 const PollVMItoStopInterval = 1 * time.Second
+const DefaultStopGracePeriod = 5 * time.Minute
+const DefaultStopTimeout = 10 * time.Minute
+const ShutdownCommandTimeout = 30 * time.Second
 const SetupConnectionDelay = 2 * time.Second
 
+const SSHConnectTimeout = 30 * time.Second
+const SSHServerAliveInterval = 15 * time.Second
+const SSHServerAliveCountMax = 3
+
+const WinRMConnectTimeout = 30 * time.Second
+const WinRMCloseTimeout = 5 * time.Second
+
+const PollSnapshotInterval = 2 * time.Second
+const RestoreSnapshotTimeout = 10 * time.Minute
+
+const DefaultRetryBackoff = 10 * time.Second
+const MaxRetryBackoff = 10 * time.Minute
+const MaxAttempts = 100
+
+const PollReadinessGateInterval = 3 * time.Second
+const ReadinessProbeTimeout = 1 * time.Minute
+
+const AnsiblePlaybookCommand = "ansible-playbook"
+
+const PollGuestAgentExecStatusInterval = 1 * time.Second
+const GuestAgentKillTimeout = 30 * time.Second
+const DefaultGuestAgentShell = "/bin/sh -c"
+
 const EmptyConnectionSecretName = "__empty__"
+
+type RestoreSnapshotPolicy string
+
+const (
+	NeverRestoreSnapshot     RestoreSnapshotPolicy = "never"
+	OnFailureRestoreSnapshot RestoreSnapshotPolicy = "on-failure"
+	AlwaysRestoreSnapshot    RestoreSnapshotPolicy = "always"
+)
+
+type StopMode string
+
+const (
+	ACPIStopMode         StopMode = "acpi"
+	GuestCommandStopMode StopMode = "guest-command"
+)
+
+// Default shutdown commands of the guest-command stop mode
+const (
+	DefaultSSHShutdownCommand        = "sudo shutdown -h now"
+	DefaultWinRMShutdownCommand      = "shutdown /s /t 0"
+	DefaultGuestAgentShutdownCommand = "shutdown -h now"
+)
+
+type ReadinessGateType string
+
+const (
+	GuestAgentReadinessGate ReadinessGateType = "guest-agent"
+	AuthReadinessGate       ReadinessGateType = "auth"
+	ProbeReadinessGate      ReadinessGateType = "probe"
+)
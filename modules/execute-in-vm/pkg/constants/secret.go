@@ -3,7 +3,9 @@ package constants
 type ExecSecretType string
 
 const (
-	SSHSecretType ExecSecretType = "ssh"
+	SSHSecretType        ExecSecretType = "ssh"
+	WinRMSecretType      ExecSecretType = "winrm"
+	GuestAgentSecretType ExecSecretType = "guest-agent"
 )
 
 const (
@@ -15,6 +15,8 @@ type attributes struct {
 	secretType constants.ExecSecretType
 	secretPath string
 	ssh        SSHAttributes
+	winRM      WinRMAttributes
+	guestAgent GuestAgentAttributes
 }
 
 func NewExecAttributes() ExecAttributes {
@@ -26,6 +28,8 @@ type ExecAttributes interface {
 	Init(execAttributesPath string) error
 	GetType() constants.ExecSecretType
 	GetSSHAttributes() SSHAttributes
+	GetWinRMAttributes() WinRMAttributes
+	GetGuestAgentAttributes() GuestAgentAttributes
 }
 
 func (s *attributes) Init(execAttributesPath string) error {
@@ -50,7 +54,7 @@ func (s *attributes) Init(execAttributesPath string) error {
 	secretTypeRaw = strings.TrimSpace(secretTypeRaw)
 
 	switch secretTypeRaw {
-	case string(constants.SSHSecretType):
+	case string(constants.SSHSecretType), string(constants.WinRMSecretType), string(constants.GuestAgentSecretType):
 		s.secretType = constants.ExecSecretType(secretTypeRaw)
 	default:
 		if sshPrivateKey != "" || sshPrivateKeyAlternativeFormat != "" {
@@ -69,6 +73,16 @@ func (s *attributes) Init(execAttributesPath string) error {
 		if err := s.ssh.initSSH(s.secretPath); err != nil {
 			return err
 		}
+	case constants.WinRMSecretType:
+		s.winRM = NewWinRMAttributes()
+		if err := s.winRM.initWinRM(s.secretPath); err != nil {
+			return err
+		}
+	case constants.GuestAgentSecretType:
+		s.guestAgent = NewGuestAgentAttributes()
+		if err := s.guestAgent.initGuestAgent(s.secretPath); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -82,12 +96,25 @@ func (s *attributes) GetSSHAttributes() SSHAttributes {
 	return s.ssh
 }
 
+func (s *attributes) GetWinRMAttributes() WinRMAttributes {
+	return s.winRM
+}
+
+func (s *attributes) GetGuestAgentAttributes() GuestAgentAttributes {
+	return s.guestAgent
+}
+
 func (s *attributes) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
 	encoder.AddString("secretType", string(s.secretType))
 	encoder.AddString("secretPath", s.secretPath)
-	if s.ssh == nil {
-		return encoder.AddReflected("ssh", s.ssh)
-	} else {
+	switch {
+	case s.ssh != nil:
 		return encoder.AddObject("ssh", s.ssh)
+	case s.winRM != nil:
+		return encoder.AddObject("winrm", s.winRM)
+	case s.guestAgent != nil:
+		return encoder.AddObject("guestAgent", s.guestAgent)
+	default:
+		return encoder.AddReflected("ssh", s.ssh)
 	}
 }
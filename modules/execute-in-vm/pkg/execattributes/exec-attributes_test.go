@@ -96,4 +96,22 @@ var _ = Describe("ExecAttributes", func() {
 			}),
 		}),
 	)
+
+	DescribeTable("guest-agent type does not require other attributes", func(secretSetup map[string]string, expectedShell []string) {
+		secretSetup["type"] = "guest-agent"
+		PrepareTestSecret(testSecretPath, secretSetup)
+		attributes := execattributes.NewExecAttributes()
+
+		Expect(attributes.Init(testSecretPath)).Should(Succeed())
+		Expect(attributes.GetType()).To(Equal(constants.GuestAgentSecretType))
+		Expect(attributes.GetSSHAttributes()).To(BeNil())
+		Expect(attributes.GetWinRMAttributes()).To(BeNil())
+		Expect(attributes.GetGuestAgentAttributes().GetShell()).To(Equal(expectedShell))
+		log.Logger().Debug(CurrentSpecReport().FullText(), zap.Object("execAttributes", attributes)) // test MarshalLogObject
+	},
+		Entry("default shell", map[string]string{}, nil),
+		Entry("custom shell", map[string]string{
+			"shell": " powershell.exe  -Command\n",
+		}, []string{"powershell.exe", "-Command"}),
+	)
 })
@@ -0,0 +1,46 @@
+package execattributes
+
+import (
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/env/fileoptions"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zconstants/connectionsecret"
+	"go.uber.org/zap/zapcore"
+	"path"
+	"strings"
+)
+
+type guestAgentAttributes struct {
+	shell []string
+}
+
+type GuestAgentAttributes interface {
+	zapcore.ObjectMarshaler
+	initGuestAgent(execSecretPath string) error
+	// GetShell returns the shell command the script is passed to as the last argument; empty if not set
+	GetShell() []string
+}
+
+func NewGuestAgentAttributes() GuestAgentAttributes {
+	return &guestAgentAttributes{}
+}
+
+func (g *guestAgentAttributes) initGuestAgent(execSecretPath string) error {
+	var shell string
+
+	if err := fileoptions.ReadFileOption(&shell, path.Join(execSecretPath, connectionsecret.GuestAgentConnectionSecretKeys.Shell)); err != nil {
+		return err
+	}
+
+	if shell = strings.TrimSpace(shell); shell != "" {
+		g.shell = strings.Fields(shell)
+	}
+	return nil
+}
+
+func (g *guestAgentAttributes) GetShell() []string {
+	return g.shell
+}
+
+func (g *guestAgentAttributes) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddString("shell", strings.Join(g.shell, " "))
+	return nil
+}
@@ -2,23 +2,22 @@ package execattributes
 
 import (
 	"fmt"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/env/fileoptions"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/options"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zconstants/connectionsecret"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zerrors"
 	"go.uber.org/zap/zapcore"
-	"os/user"
 	"path"
 	"strings"
+	"time"
 )
 
 const (
 	sshStrictHostKeyCheckingOption = "StrictHostKeyChecking"
-)
-
-const (
-	sshDirName        = ".ssh"
-	sshExecutableName = "ssh"
+	sshServerAliveIntervalOption   = "ServerAliveInterval"
+	sshServerAliveCountMaxOption   = "ServerAliveCountMax"
+	sshConnectTimeoutOption        = "ConnectTimeout"
 )
 
 const (
@@ -27,27 +26,34 @@ const (
 	yes       = "yes"
 )
 
-// port is parsed from additionalSSHOptions to be more easier to use
+// port and supported -o options are parsed from additionalSSHOptions to be more easier to use
 type sshAttributes struct {
 	user                         string
+	password                     string
 	port                         int
 	additionalSSHOptions         []string
 	privateKey                   string
 	hostPublicKey                string
 	disableStrictHostKeyChecking bool
+	strictHostKeyCheckingMode    string
+	serverAliveInterval          time.Duration
+	serverAliveCountMax          int
+	connectTimeout               time.Duration
 }
 
 type SSHAttributes interface {
 	zapcore.ObjectMarshaler
 	initSSH(execSecretPath string) error
 	GetUser() string
+	GetPassword() string
 	GetPort() int
 	GetAdditionalSSHOptions() []string
 	GetPrivateKey() string
 	GetHostPublicKey() string
 	GetStrictHostKeyCheckingMode() string
-	GetSSHDir() string
-	GetSSHExecutableName() string
+	GetServerAliveInterval() time.Duration
+	GetServerAliveCountMax() int
+	GetConnectTimeout() time.Duration
 }
 
 func NewSSHAttributes() SSHAttributes {
@@ -59,6 +65,7 @@ func (s *sshAttributes) initSSH(execSecretPath string) error {
 
 	stringOptions := map[string]*string{
 		connectionsecret.SSHConnectionSecretKeys.User:                        &s.user,
+		connectionsecret.SSHConnectionSecretKeys.Password:                    &s.password,
 		connectionsecret.SSHConnectionSecretKeys.AdditionalSSHOptions:        &additionalSSHOptionsString,
 		connectionsecret.SSHConnectionSecretKeys.PrivateKey:                  &s.privateKey,
 		connectionsecret.SSHConnectionSecretKeys.PrivateKeyAlternativeFormat: &privateKeyAlternativeFormat,
@@ -83,8 +90,8 @@ func (s *sshAttributes) initSSH(execSecretPath string) error {
 	if strings.TrimSpace(s.privateKey) == "" {
 		if strings.TrimSpace(privateKeyAlternativeFormat) != "" {
 			s.privateKey = privateKeyAlternativeFormat
-		} else {
-			return zerrors.NewMissingRequiredError("%v secret attribute is required", connectionsecret.SSHConnectionSecretKeys.PrivateKey)
+		} else if s.password == "" {
+			return zerrors.NewMissingRequiredError("%v or %v secret attribute is required", connectionsecret.SSHConnectionSecretKeys.PrivateKey, connectionsecret.SSHConnectionSecretKeys.Password)
 		}
 	}
 
@@ -106,16 +113,41 @@ func (s *sshAttributes) initSSH(execSecretPath string) error {
 	}
 	s.port = port
 
-	if !strings.HasSuffix(s.privateKey, "\n") {
+	if s.privateKey != "" && !strings.HasSuffix(s.privateKey, "\n") {
 		s.privateKey += "\n"
 	}
 
 	if !additionalSSHOptions.IncludesString(sshStrictHostKeyCheckingOption) {
-		additionalSSHOptions.AddOption("-o", fmt.Sprintf("%v=%v", sshStrictHostKeyCheckingOption, s.GetStrictHostKeyCheckingMode()))
+		additionalSSHOptions.AddOption("-o", fmt.Sprintf("%v=%v", sshStrictHostKeyCheckingOption, s.getDefaultStrictHostKeyCheckingMode()))
 	}
 
 	s.additionalSSHOptions = additionalSSHOptions.GetAll()
 
+	return s.initSSHConfigOptions(additionalSSHOptions)
+}
+
+func (s *sshAttributes) initSSHConfigOptions(sshOptions *options.CommandOptions) error {
+	sshConfigOptions, err := parseSSHConfigOptions(sshOptions)
+	if err != nil {
+		return err
+	}
+
+	if s.strictHostKeyCheckingMode, err = parseStrictHostKeyCheckingMode(sshConfigOptions[strings.ToLower(sshStrictHostKeyCheckingOption)]); err != nil {
+		return err
+	}
+
+	if s.serverAliveInterval, err = parseSecondsOption(sshConfigOptions, sshServerAliveIntervalOption, constants.SSHServerAliveInterval); err != nil {
+		return err
+	}
+
+	if s.connectTimeout, err = parseSecondsOption(sshConfigOptions, sshConnectTimeoutOption, constants.SSHConnectTimeout); err != nil {
+		return err
+	}
+
+	if s.serverAliveCountMax, err = parseIntOption(sshConfigOptions, sshServerAliveCountMaxOption, constants.SSHServerAliveCountMax); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -123,6 +155,10 @@ func (s *sshAttributes) GetUser() string {
 	return s.user
 }
 
+func (s *sshAttributes) GetPassword() string {
+	return s.password
+}
+
 func (s *sshAttributes) GetPort() int {
 	return s.port
 }
@@ -132,39 +168,44 @@ func (s *sshAttributes) GetAdditionalSSHOptions() []string {
 }
 
 func (s *sshAttributes) GetStrictHostKeyCheckingMode() string {
-	if s.disableStrictHostKeyChecking {
-		// TODO change to safer acceptNew once a newer version of ssh which supports this option is available in CI
-		return no
+	if s.strictHostKeyCheckingMode != "" {
+		return s.strictHostKeyCheckingMode
 	}
-	return yes
+	return s.getDefaultStrictHostKeyCheckingMode()
 }
 
-func (s *sshAttributes) GetPrivateKey() string {
-	return s.privateKey
+func (s *sshAttributes) GetServerAliveInterval() time.Duration {
+	return s.serverAliveInterval
 }
 
-func (s *sshAttributes) GetHostPublicKey() string {
-	return s.hostPublicKey
+func (s *sshAttributes) GetServerAliveCountMax() int {
+	return s.serverAliveCountMax
 }
 
-func (s *sshAttributes) GetSSHDir() string {
-	current, err := user.Current()
-
-	if err != nil {
-		panic(err)
-	}
+func (s *sshAttributes) GetConnectTimeout() time.Duration {
+	return s.connectTimeout
+}
 
-	return path.Join(current.HomeDir, sshDirName)
+func (s *sshAttributes) GetPrivateKey() string {
+	return s.privateKey
 }
 
-func (s *sshAttributes) GetSSHExecutableName() string {
-	return sshExecutableName
+func (s *sshAttributes) GetHostPublicKey() string {
+	return s.hostPublicKey
 }
 
 func (s *sshAttributes) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
-	// do not print private/public key
+	// do not print private/public key and password
 	encoder.AddString("user", s.user)
 	encoder.AddString("additionalSSHOptions", strings.Join(s.additionalSSHOptions, " "))
 	encoder.AddBool("disableStrictHostKeyChecking", s.disableStrictHostKeyChecking)
 	return nil
 }
+
+func (s *sshAttributes) getDefaultStrictHostKeyCheckingMode() string {
+	if s.disableStrictHostKeyChecking {
+		// TODO change to safer acceptNew once a newer version of ssh which supports this option is available in CI
+		return no
+	}
+	return yes
+}
@@ -1,17 +1,20 @@
 package execattributes_test
 
 import (
+	"fmt"
 	"os"
-	"os/user"
 	"path"
 	"reflect"
+	"time"
 
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execattributes"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
 	. "github.com/kubevirt/kubevirt-tekton-tasks/modules/sharedtest/testconstants"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 var _ = Describe("SSHAttributes", func() {
@@ -39,7 +42,7 @@ var _ = Describe("SSHAttributes", func() {
 		Expect(err.Error()).To(ContainSubstring(expectedErrMessage))
 		log.Logger().Debug(CurrentSpecReport().FullText(), zap.Object("execAttributes", attributes)) // test MarshalLogObject
 	},
-		Entry("privatekey missing", "ssh-privatekey secret attribute is required", map[string]string{}),
+		Entry("privatekey and password missing", "ssh-privatekey or password secret attribute is required", map[string]string{}),
 		Entry("user missing", "user secret attribute is required", map[string]string{
 			"ssh-privatekey": SSHTestPrivateKey,
 		}),
@@ -59,6 +62,24 @@ var _ = Describe("SSHAttributes", func() {
 			"host-public-key":        SSHTestPublicKey,
 			"additional-ssh-options": "-C -p 22.0",
 		}),
+		Entry("no config option value", "no argument after keyword \"ConnectTimeout\"", map[string]string{
+			"user":                   "fedora",
+			"ssh-privatekey":         SSHTestPrivateKey,
+			"host-public-key":        SSHTestPublicKey,
+			"additional-ssh-options": "-oConnectTimeout",
+		}),
+		Entry("bad StrictHostKeyChecking argument", "unsupported StrictHostKeyChecking value 'maybe'", map[string]string{
+			"user":                   "fedora",
+			"ssh-privatekey":         SSHTestPrivateKey,
+			"host-public-key":        SSHTestPublicKey,
+			"additional-ssh-options": "-o StrictHostKeyChecking=maybe",
+		}),
+		Entry("bad ServerAliveInterval argument", "invalid ServerAliveInterval value '5s'", map[string]string{
+			"user":                   "fedora",
+			"ssh-privatekey":         SSHTestPrivateKey,
+			"host-public-key":        SSHTestPublicKey,
+			"additional-ssh-options": "-o ServerAliveInterval=5s",
+		}),
 	)
 
 	DescribeTable("test various sshAttributes", func(secretSetup map[string]string, expectedAttributes map[string]interface{}) {
@@ -133,6 +154,26 @@ var _ = Describe("SSHAttributes", func() {
 			"GetHostPublicKey":             SSHTestPublicKey,
 			"GetStrictHostKeyCheckingMode": "yes",
 		}),
+		Entry("password setup", map[string]string{
+			"type":            "ssh",
+			"user":            "Administrator",
+			"password":        "my secret password",
+			"host-public-key": SSHTestPublicKey,
+		}, map[string]interface{}{
+			"GetUser":       "Administrator",
+			"GetPassword":   "my secret password",
+			"GetPrivateKey": "",
+		}),
+		Entry("password and private key setup", map[string]string{
+			"type":            "ssh",
+			"user":            "fedora",
+			"password":        "fedora",
+			"ssh-privatekey":  SSHTestPrivateKey,
+			"host-public-key": SSHTestPublicKey,
+		}, map[string]interface{}{
+			"GetPassword":   "fedora",
+			"GetPrivateKey": SSHTestPrivateKey,
+		}),
 		Entry("end newline in private key", map[string]string{
 			"type":            "ssh",
 			"user":            "fedora",
@@ -151,6 +192,49 @@ var _ = Describe("SSHAttributes", func() {
 			"GetPort":                 8022,
 			"GetAdditionalSSHOptions": []string{"-C", "-p8022", "-l=test", "-o", "StrictHostKeyChecking=yes"},
 		}),
+		Entry("parse ssh config options", map[string]string{
+			"type":                   "ssh",
+			"user":                   "fedora",
+			"ssh-privatekey":         SSHTestPrivateKey,
+			"host-public-key":        SSHTestPublicKey,
+			"additional-ssh-options": "-oStrictHostKeyChecking=accept-new -o ConnectTimeout=5 -o serveraliveinterval=10 -o \"ServerAliveCountMax 7\"",
+		}, map[string]interface{}{
+			"GetStrictHostKeyCheckingMode": "accept-new",
+			"GetConnectTimeout":            5 * time.Second,
+			"GetServerAliveInterval":       10 * time.Second,
+			"GetServerAliveCountMax":       7,
+		}),
+		Entry("StrictHostKeyChecking option overrides disable-strict-host-key-checking", map[string]string{
+			"type":                             "ssh",
+			"user":                             "fedora",
+			"ssh-privatekey":                   SSHTestPrivateKey,
+			"host-public-key":                  SSHTestPublicKey,
+			"disable-strict-host-key-checking": "true",
+			"additional-ssh-options":           "-o StrictHostKeyChecking=yes",
+		}, map[string]interface{}{
+			"GetAdditionalSSHOptions":      []string{"-o", "StrictHostKeyChecking=yes"},
+			"GetStrictHostKeyCheckingMode": "yes",
+		}),
+		Entry("disabled keepalive", map[string]string{
+			"type":                             "ssh",
+			"user":                             "fedora",
+			"ssh-privatekey":                   SSHTestPrivateKey,
+			"disable-strict-host-key-checking": "true",
+			"additional-ssh-options":           "-o ServerAliveInterval=0 -oStrictHostKeyChecking=off",
+		}, map[string]interface{}{
+			"GetServerAliveInterval":       0 * time.Second,
+			"GetStrictHostKeyCheckingMode": "no",
+		}),
+		Entry("keepalive without closing unresponsive connections", map[string]string{
+			"type":                   "ssh",
+			"user":                   "fedora",
+			"ssh-privatekey":         SSHTestPrivateKey,
+			"host-public-key":        SSHTestPublicKey,
+			"additional-ssh-options": "-o ServerAliveCountMax=0",
+		}, map[string]interface{}{
+			"GetServerAliveInterval": constants.SSHServerAliveInterval,
+			"GetServerAliveCountMax": 0,
+		}),
 	)
 
 	It("does common operations correctly", func() {
@@ -167,15 +251,31 @@ var _ = Describe("SSHAttributes", func() {
 
 		sshAttributes := attributes.GetSSHAttributes()
 
-		// GetSSHDir
-		current, err := user.Current()
-		Expect(err).Should(Succeed())
-		homeDir := current.HomeDir
-		Expect(sshAttributes.GetSSHDir()).To(Equal(path.Join(homeDir, ".ssh")))
-
-		// GetSSHExecutableName
-		Expect(sshAttributes.GetSSHExecutableName()).To(Equal("ssh"))
+		// defaults
+		Expect(sshAttributes.GetConnectTimeout()).To(Equal(constants.SSHConnectTimeout))
+		Expect(sshAttributes.GetServerAliveInterval()).To(Equal(constants.SSHServerAliveInterval))
+		Expect(sshAttributes.GetServerAliveCountMax()).To(Equal(constants.SSHServerAliveCountMax))
 
 		log.Logger().Info(CurrentSpecReport().FullText(), zap.Object("execAttributes", attributes)) // test MarshalLogObject
 	})
+
+	It("does not log secrets", func() {
+		PrepareTestSecret(testSecretPath, map[string]string{
+			"type":            "ssh",
+			"user":            "fedora",
+			"password":        "my-secret-password",
+			"ssh-privatekey":  SSHTestPrivateKey,
+			"host-public-key": SSHTestPublicKey,
+		})
+		attributes := execattributes.NewExecAttributes()
+		Expect(attributes.Init(testSecretPath)).Should(Succeed())
+
+		encoder := zapcore.NewMapObjectEncoder()
+		Expect(attributes.MarshalLogObject(encoder)).Should(Succeed())
+
+		logged := fmt.Sprintf("%v", encoder.Fields)
+		Expect(logged).To(ContainSubstring("fedora"))
+		Expect(logged).ToNot(ContainSubstring("my-secret-password"))
+		Expect(logged).ToNot(ContainSubstring(SSHTestPrivateKey))
+	})
 })
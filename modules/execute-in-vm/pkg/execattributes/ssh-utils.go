@@ -4,6 +4,8 @@ import (
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/options"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zerrors"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const (
@@ -27,3 +29,71 @@ func parsePort(sshOptions *options.CommandOptions) (int, error) {
 	}
 	return port, nil
 }
+
+// parseSSHConfigOptions collects all -o options into a map with lowercase keys (ssh keywords are case-insensitive)
+func parseSSHConfigOptions(sshOptions *options.CommandOptions) (map[string]string, error) {
+	result := map[string]string{}
+	opts := sshOptions.GetAll()
+
+	for idx := 0; idx < len(opts); idx++ {
+		if !strings.HasPrefix(opts[idx], "-o") {
+			continue
+		}
+
+		configOption := strings.TrimPrefix(opts[idx], "-o")
+		if configOption == "" {
+			if idx+1 >= len(opts) {
+				return nil, zerrors.NewMissingRequiredError("ssh option requires an argument -- o")
+			}
+			idx++
+			configOption = opts[idx]
+		}
+
+		keyValue := strings.SplitN(strings.Replace(configOption, "=", " ", 1), " ", 2)
+		if len(keyValue) != 2 || strings.TrimSpace(keyValue[1]) == "" {
+			return nil, zerrors.NewMissingRequiredError("no argument after keyword \"%v\"", keyValue[0])
+		}
+
+		key := strings.ToLower(strings.TrimSpace(keyValue[0]))
+		// first obtained value is used
+		if _, exists := result[key]; !exists {
+			result[key] = strings.TrimSpace(keyValue[1])
+		}
+	}
+
+	return result, nil
+}
+
+func parseStrictHostKeyCheckingMode(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case yes, "ask":
+		return yes, nil
+	case no, "off":
+		return no, nil
+	case acceptNew:
+		return acceptNew, nil
+	default:
+		return "", zerrors.NewMissingRequiredError("unsupported %v value '%v'", sshStrictHostKeyCheckingOption, value)
+	}
+}
+
+func parseSecondsOption(sshConfigOptions map[string]string, option string, defaultValue time.Duration) (time.Duration, error) {
+	seconds, err := parseIntOption(sshConfigOptions, option, int(defaultValue/time.Second))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+func parseIntOption(sshConfigOptions map[string]string, option string, defaultValue int) (int, error) {
+	valueStr, exists := sshConfigOptions[strings.ToLower(option)]
+	if !exists {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value < 0 {
+		return 0, zerrors.NewMissingRequiredError("invalid %v value '%v'", option, valueStr)
+	}
+	return value, nil
+}
@@ -0,0 +1,122 @@
+package execattributes
+
+import (
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/env/fileoptions"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zconstants/connectionsecret"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zerrors"
+	"go.uber.org/zap/zapcore"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultWinRMHTTPPort  = 5985
+	defaultWinRMHTTPSPort = 5986
+)
+
+type winRMAttributes struct {
+	user                         string
+	password                     string
+	port                         int
+	useHTTPS                     bool
+	disableCertificateValidation bool
+	caCert                       string
+}
+
+type WinRMAttributes interface {
+	zapcore.ObjectMarshaler
+	initWinRM(execSecretPath string) error
+	GetUser() string
+	GetPassword() string
+	GetPort() int
+	UseHTTPS() bool
+	IsCertificateValidationDisabled() bool
+	GetCACert() string
+}
+
+func NewWinRMAttributes() WinRMAttributes {
+	return &winRMAttributes{}
+}
+
+func (w *winRMAttributes) initWinRM(execSecretPath string) error {
+	var portStr string
+
+	stringOptions := map[string]*string{
+		connectionsecret.WinRMConnectionSecretKeys.User:     &w.user,
+		connectionsecret.WinRMConnectionSecretKeys.Password: &w.password,
+		connectionsecret.WinRMConnectionSecretKeys.Port:     &portStr,
+		connectionsecret.WinRMConnectionSecretKeys.CACert:   &w.caCert,
+	}
+	boolOptions := map[string]*bool{
+		connectionsecret.WinRMConnectionSecretKeys.UseHTTPS:                     &w.useHTTPS,
+		connectionsecret.WinRMConnectionSecretKeys.DisableCertificateValidation: &w.disableCertificateValidation,
+	}
+
+	for optionName, output := range stringOptions {
+		if err := fileoptions.ReadFileOption(output, path.Join(execSecretPath, optionName)); err != nil {
+			return err
+		}
+	}
+
+	for optionName, output := range boolOptions {
+		if err := fileoptions.ReadFileOptionBool(output, path.Join(execSecretPath, optionName)); err != nil {
+			return err
+		}
+	}
+
+	if w.user == "" {
+		return zerrors.NewMissingRequiredError("%v secret attribute is required", connectionsecret.WinRMConnectionSecretKeys.User)
+	}
+
+	if w.password == "" {
+		return zerrors.NewMissingRequiredError("%v secret attribute is required", connectionsecret.WinRMConnectionSecretKeys.Password)
+	}
+
+	if portStr = strings.TrimSpace(portStr); portStr != "" {
+		port, err := strconv.Atoi(portStr)
+		if err != nil || port <= 0 {
+			return zerrors.NewMissingRequiredError("Bad port '%v'", portStr)
+		}
+		w.port = port
+	} else if w.useHTTPS {
+		w.port = defaultWinRMHTTPSPort
+	} else {
+		w.port = defaultWinRMHTTPPort
+	}
+
+	return nil
+}
+
+func (w *winRMAttributes) GetUser() string {
+	return w.user
+}
+
+func (w *winRMAttributes) GetPassword() string {
+	return w.password
+}
+
+func (w *winRMAttributes) GetPort() int {
+	return w.port
+}
+
+func (w *winRMAttributes) UseHTTPS() bool {
+	return w.useHTTPS
+}
+
+func (w *winRMAttributes) IsCertificateValidationDisabled() bool {
+	return w.disableCertificateValidation
+}
+
+func (w *winRMAttributes) GetCACert() string {
+	return w.caCert
+}
+
+func (w *winRMAttributes) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	// do not print password
+	encoder.AddString("user", w.user)
+	encoder.AddInt("port", w.port)
+	encoder.AddBool("useHTTPS", w.useHTTPS)
+	encoder.AddBool("disableCertificateValidation", w.disableCertificateValidation)
+	return nil
+}
@@ -0,0 +1,133 @@
+package execattributes_test
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execattributes"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
+	. "github.com/kubevirt/kubevirt-tekton-tasks/modules/sharedtest/testconstants"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var _ = Describe("WinRMAttributes", func() {
+	var testSecretPath string
+
+	BeforeEach(func() {
+		testSecretPath = path.Join(testPath, TestRandomName("winrm-attr-secret"))
+		err := os.MkdirAll(testSecretPath, testDirMode)
+		Expect(err).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		err := os.RemoveAll(testSecretPath)
+		Expect(err).Should(Succeed())
+	})
+
+	DescribeTable("Init fails", func(expectedErrMessage string, secretSetup map[string]string) {
+		secretSetup["type"] = "winrm"
+
+		PrepareTestSecret(testSecretPath, secretSetup)
+		attributes := execattributes.NewExecAttributes()
+
+		err := attributes.Init(testSecretPath)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(expectedErrMessage))
+		log.Logger().Debug(CurrentSpecReport().FullText(), zap.Object("execAttributes", attributes)) // test MarshalLogObject
+	},
+		Entry("user missing", "user secret attribute is required", map[string]string{
+			"password": "secret",
+		}),
+		Entry("password missing", "password secret attribute is required", map[string]string{
+			"user": "Administrator",
+		}),
+		Entry("bad port", "Bad port '59.85'", map[string]string{
+			"user":     "Administrator",
+			"password": "secret",
+			"port":     "59.85",
+		}),
+	)
+
+	DescribeTable("test various winRMAttributes", func(secretSetup map[string]string, expectedAttributes map[string]interface{}) {
+		secretSetup["type"] = "winrm"
+
+		PrepareTestSecret(testSecretPath, secretSetup)
+		attributes := execattributes.NewExecAttributes()
+
+		err := attributes.Init(testSecretPath)
+		Expect(err).Should(Succeed())
+		Expect(attributes.GetSSHAttributes()).To(BeNil())
+
+		winRMAttributes := attributes.GetWinRMAttributes()
+
+		for methodName, expectedValue := range expectedAttributes {
+			results := reflect.ValueOf(winRMAttributes).MethodByName(methodName).Call([]reflect.Value{})
+			Expect(results[0].Interface()).To(Equal(expectedValue))
+		}
+
+		log.Logger().Info(CurrentSpecReport().FullText(), zap.Object("execAttributes", attributes)) // test MarshalLogObject
+	},
+		Entry("minimal setup", map[string]string{
+			"user":     "Administrator",
+			"password": "secret",
+		}, map[string]interface{}{
+			"GetUser":                         "Administrator",
+			"GetPassword":                     "secret",
+			"GetPort":                         5985,
+			"UseHTTPS":                        false,
+			"IsCertificateValidationDisabled": false,
+			"GetCACert":                       "",
+		}),
+		Entry("https setup", map[string]string{
+			"user":      "Administrator",
+			"password":  "secret",
+			"use-https": "true",
+			"ca-cert":   "my-ca",
+		}, map[string]interface{}{
+			"GetPort":                         5986,
+			"UseHTTPS":                        true,
+			"IsCertificateValidationDisabled": false,
+			"GetCACert":                       "my-ca",
+		}),
+		Entry("https without certificate validation", map[string]string{
+			"user":                           "Administrator",
+			"password":                       "secret",
+			"use-https":                      "true",
+			"disable-certificate-validation": "true",
+		}, map[string]interface{}{
+			"GetPort":                         5986,
+			"UseHTTPS":                        true,
+			"IsCertificateValidationDisabled": true,
+		}),
+		Entry("custom port", map[string]string{
+			"user":     "Administrator",
+			"password": "secret",
+			"port":     "8080\n",
+		}, map[string]interface{}{
+			"GetPort":  8080,
+			"UseHTTPS": false,
+		}),
+	)
+
+	It("does not log secrets", func() {
+		PrepareTestSecret(testSecretPath, map[string]string{
+			"type":     "winrm",
+			"user":     "Administrator",
+			"password": "my-secret-password",
+		})
+		attributes := execattributes.NewExecAttributes()
+		Expect(attributes.Init(testSecretPath)).Should(Succeed())
+
+		encoder := zapcore.NewMapObjectEncoder()
+		Expect(attributes.MarshalLogObject(encoder)).Should(Succeed())
+
+		logged := fmt.Sprintf("%v", encoder.Fields)
+		Expect(logged).To(ContainSubstring("Administrator"))
+		Expect(logged).ToNot(ContainSubstring("my-secret-password"))
+	})
+})
@@ -0,0 +1,264 @@
+package execute
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/cmd"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execattributes"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zconstants/connectionsecret"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zerrors"
+	"go.uber.org/zap"
+)
+
+const (
+	playbookTaskOK          = "ok"
+	playbookTaskChanged     = "changed"
+	playbookTaskFailed      = "failed"
+	playbookTaskSkipped     = "skipped"
+	playbookTaskUnreachable = "unreachable"
+)
+
+// playbookTaskResult is the outcome of a single playbook task on the VM
+type playbookTaskResult struct {
+	Play    string
+	Task    string
+	Status  string
+	Message string
+}
+
+func (r playbookTaskResult) String() string {
+	return fmt.Sprintf("%v: [%v] %v", r.Status, r.Play, r.Task)
+}
+
+// playbookOutput is the subset of the output of the ansible json stdout callback used to report task results
+type playbookOutput struct {
+	Plays []struct {
+		Play struct {
+			Name string `json:"name"`
+		} `json:"play"`
+		Tasks []struct {
+			Task struct {
+				Name string `json:"name"`
+			} `json:"task"`
+			Hosts map[string]struct {
+				Changed     bool   `json:"changed"`
+				Failed      bool   `json:"failed"`
+				Skipped     bool   `json:"skipped"`
+				Unreachable bool   `json:"unreachable"`
+				Message     string `json:"msg"`
+			} `json:"hosts"`
+		} `json:"tasks"`
+	} `json:"plays"`
+}
+
+// playbookRunner runs an Ansible playbook against the VM with an inventory generated from the connection secret
+type playbookRunner struct {
+	execAttributes execattributes.ExecAttributes
+	hostName       string
+	playbook       string
+	playbookFile   string
+}
+
+func newPlaybookRunner(execAttributes execattributes.ExecAttributes, hostName, playbook, playbookFile string) (*playbookRunner, error) {
+	// the winrm connection plugin needs pywinrm, which is not part of the image
+	if execAttributes.GetType() != constants.SSHSecretType {
+		return nil, fmt.Errorf("playbook is supported only by the %v secret type", constants.SSHSecretType)
+	}
+
+	// ansible needs sshpass for password authentication over ssh, which is not part of the image
+	if strings.TrimSpace(execAttributes.GetSSHAttributes().GetPrivateKey()) == "" {
+		return nil, zerrors.NewMissingRequiredError("playbook requires the %v secret attribute, password authentication over ssh is not supported",
+			connectionsecret.SSHConnectionSecretKeys.PrivateKey)
+	}
+
+	return &playbookRunner{
+		execAttributes: execAttributes,
+		hostName:       hostName,
+		playbook:       playbook,
+		playbookFile:   playbookFile,
+	}, nil
+}
+
+// Run runs the playbook against ipAddress. A summary of the task results is written to stdout.
+func (r *playbookRunner) Run(ipAddress string, timeout time.Duration, stdout, stderr io.Writer) ([]playbookTaskResult, error) {
+	workDir, err := os.MkdirTemp("", "playbook-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	playbookFile := r.playbookFile
+	if playbookFile == "" {
+		playbookFile = filepath.Join(workDir, "playbook.yaml")
+		if err := os.WriteFile(playbookFile, []byte(r.playbook), 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	inventory, err := r.generateInventory(ipAddress, workDir)
+	if err != nil {
+		return nil, err
+	}
+	inventoryFile := filepath.Join(workDir, "inventory.json")
+	if err := os.WriteFile(inventoryFile, inventory, 0600); err != nil {
+		return nil, err
+	}
+
+	var output bytes.Buffer
+	command := exec.Command(constants.AnsiblePlaybookCommand, "-i", inventoryFile, playbookFile)
+	command.Env = append(os.Environ(),
+		"ANSIBLE_STDOUT_CALLBACK=json",
+		"ANSIBLE_RETRY_FILES_ENABLED=false",
+		"ANSIBLE_NOCOLOR=true",
+	)
+	command.Stdout = &output
+	command.Stderr = stderr
+
+	// do not log playbook
+	log.Logger().Debug("running playbook", zap.String("host", r.hostName))
+	runErr := cmd.RunCmdWithTimeout(timeout, command)
+
+	results, err := parsePlaybookOutput(output.Bytes())
+	if err != nil {
+		// e.g. syntax errors are not reported by the json callback
+		log.Logger().Debug("could not parse playbook output", zap.Error(err))
+		_, _ = stdout.Write(output.Bytes())
+		return nil, runErr
+	}
+
+	for _, result := range results {
+		line := result.String()
+		if result.Message != "" && (result.Status == playbookTaskFailed || result.Status == playbookTaskUnreachable) {
+			line += ": " + result.Message
+		}
+		if _, err := fmt.Fprintln(stdout, line); err != nil {
+			return results, err
+		}
+	}
+
+	return results, runErr
+}
+
+// generateInventory returns a json inventory with a single host named after the VM and writes
+// the credentials which ansible reads from files to workDir
+func (r *playbookRunner) generateInventory(ipAddress, workDir string) ([]byte, error) {
+	hostVars := map[string]interface{}{
+		"ansible_host": ipAddress,
+	}
+
+	switch r.execAttributes.GetType() {
+	case constants.SSHSecretType:
+		sshAttributes := r.execAttributes.GetSSHAttributes()
+		hostVars["ansible_user"] = sshAttributes.GetUser()
+		hostVars["ansible_port"] = sshAttributes.GetPort()
+
+		// the password is not passed, ansible would require sshpass for it
+		privateKeyFile := filepath.Join(workDir, "id_private")
+		if err := os.WriteFile(privateKeyFile, []byte(sshAttributes.GetPrivateKey()), 0600); err != nil {
+			return nil, err
+		}
+		hostVars["ansible_ssh_private_key_file"] = privateKeyFile
+
+		// accept-new mode needs a writable known hosts file
+		knownHostsFile := filepath.Join(workDir, "known_hosts")
+		var knownHosts string
+		if hostPublicKey := strings.TrimSpace(sshAttributes.GetHostPublicKey()); hostPublicKey != "" {
+			knownHosts = fmt.Sprintf("%v %v\n", getKnownHostsAddress(ipAddress, sshAttributes.GetPort()), hostPublicKey)
+		}
+		if err := os.WriteFile(knownHostsFile, []byte(knownHosts), 0600); err != nil {
+			return nil, err
+		}
+
+		sshArgs := append(withoutSSHPortOption(sshAttributes.GetAdditionalSSHOptions()), "-o", "UserKnownHostsFile="+knownHostsFile)
+		hostVars["ansible_ssh_common_args"] = joinShellArgs(sshArgs)
+	default:
+		return nil, fmt.Errorf("invalid secret/execution type %v", r.execAttributes.GetType())
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"all": map[string]interface{}{
+			"hosts": map[string]interface{}{
+				r.hostName: hostVars,
+			},
+		},
+	})
+}
+
+func parsePlaybookOutput(output []byte) ([]playbookTaskResult, error) {
+	var parsedOutput playbookOutput
+	if err := json.Unmarshal(output, &parsedOutput); err != nil {
+		return nil, err
+	}
+
+	var results []playbookTaskResult
+	for _, play := range parsedOutput.Plays {
+		for _, task := range play.Tasks {
+			for _, host := range task.Hosts {
+				result := playbookTaskResult{
+					Play:    play.Play.Name,
+					Task:    task.Task.Name,
+					Status:  playbookTaskOK,
+					Message: host.Message,
+				}
+				switch {
+				case host.Unreachable:
+					result.Status = playbookTaskUnreachable
+				case host.Failed:
+					result.Status = playbookTaskFailed
+				case host.Skipped:
+					result.Status = playbookTaskSkipped
+				case host.Changed:
+					result.Status = playbookTaskChanged
+				}
+				results = append(results, result)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func getKnownHostsAddress(ipAddress string, port int) string {
+	// the default port is omitted in known hosts
+	if port == 22 {
+		return ipAddress
+	}
+	return "[" + ipAddress + "]:" + strconv.Itoa(port)
+}
+
+// withoutSSHPortOption removes the port option because ansible sets the port itself
+func withoutSSHPortOption(sshOptions []string) []string {
+	var result []string
+	for idx := 0; idx < len(sshOptions); idx++ {
+		switch {
+		case sshOptions[idx] == "-p":
+			idx++
+		case strings.HasPrefix(sshOptions[idx], "-p"):
+		default:
+			result = append(result, sshOptions[idx])
+		}
+	}
+	return result
+}
+
+func joinShellArgs(args []string) string {
+	quotedArgs := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'"'"'`) + "'"
+		}
+		quotedArgs = append(quotedArgs, arg)
+	}
+	return strings.Join(quotedArgs, " ")
+}
@@ -0,0 +1,176 @@
+package execute_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execattributes"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execute"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	. "github.com/kubevirt/kubevirt-tekton-tasks/modules/sharedtest/testconstants"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const playbookOutput = `{
+  "plays": [
+    {
+      "play": {"name": "customize"},
+      "tasks": [
+        {"task": {"name": "Gathering Facts"}, "hosts": {"vm": {"changed": false}}},
+        {"task": {"name": "install packages"}, "hosts": {"vm": {"changed": true}}},
+        {"task": {"name": "configure windows"}, "hosts": {"vm": {"changed": false, "skipped": true}}},
+        {"task": {"name": "start service"}, "hosts": {"vm": {"changed": false, "failed": true, "msg": "service not found"}}}
+      ]
+    }
+  ],
+  "stats": {"vm": {"ok": 2, "changed": 1, "failures": 1, "skipped": 1, "unreachable": 0}}
+}`
+
+var _ = Describe("PlaybookRunner", func() {
+	var testSecretPath, workDir string
+
+	BeforeEach(func() {
+		testSecretPath = path.Join(testPath, TestRandomName("playbook-secret"))
+		Expect(os.MkdirAll(testSecretPath, testDirMode)).Should(Succeed())
+		workDir = path.Join(testPath, TestRandomName("playbook-workdir"))
+		Expect(os.MkdirAll(workDir, testDirMode)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(testSecretPath)).Should(Succeed())
+		Expect(os.RemoveAll(workDir)).Should(Succeed())
+	})
+
+	newExecAttributes := func(secret map[string]string) execattributes.ExecAttributes {
+		PrepareTestSecret(testSecretPath, secret)
+		attributes := execattributes.NewExecAttributes()
+		Expect(attributes.Init(testSecretPath)).Should(Succeed())
+		return attributes
+	}
+
+	generateHostVars := func(secret map[string]string, ipAddress string) map[string]interface{} {
+		runner, err := execute.NewPlaybookRunner(newExecAttributes(secret), "vm", "- hosts: all", "")
+		Expect(err).Should(Succeed())
+
+		inventory, err := runner.GenerateInventory(ipAddress, workDir)
+		Expect(err).Should(Succeed())
+
+		var parsedInventory map[string]map[string]map[string]map[string]interface{}
+		Expect(json.Unmarshal(inventory, &parsedInventory)).Should(Succeed())
+		Expect(parsedInventory["all"]["hosts"]).To(HaveKey("vm"))
+		return parsedInventory["all"]["hosts"]["vm"]
+	}
+
+	It("generates ssh inventory", func() {
+		hostVars := generateHostVars(map[string]string{
+			"type":                   "ssh",
+			"user":                   "fedora",
+			"ssh-privatekey":         SSHTestPrivateKey,
+			"host-public-key":        SSHTestPublicKey,
+			"additional-ssh-options": "-C -p 8022",
+		}, "10.0.0.5")
+
+		privateKeyFile := filepath.Join(workDir, "id_private")
+		knownHostsFile := filepath.Join(workDir, "known_hosts")
+		Expect(hostVars).To(Equal(map[string]interface{}{
+			"ansible_host":                 "10.0.0.5",
+			"ansible_user":                 "fedora",
+			"ansible_port":                 float64(8022),
+			"ansible_ssh_private_key_file": privateKeyFile,
+			"ansible_ssh_common_args":      "-C -o StrictHostKeyChecking=yes -o UserKnownHostsFile=" + knownHostsFile,
+		}))
+
+		privateKey, err := os.ReadFile(privateKeyFile)
+		Expect(err).Should(Succeed())
+		Expect(string(privateKey)).To(Equal(SSHTestPrivateKey))
+
+		knownHosts, err := os.ReadFile(knownHostsFile)
+		Expect(err).Should(Succeed())
+		Expect(string(knownHosts)).To(HavePrefix("[10.0.0.5]:8022 ssh-"))
+	})
+
+	It("generates ssh inventory without password", func() {
+		hostVars := generateHostVars(map[string]string{
+			"type":                             "ssh",
+			"user":                             "fedora",
+			"ssh-privatekey":                   SSHTestPrivateKey,
+			"password":                         "my secret password",
+			"disable-strict-host-key-checking": "true",
+		}, "fd10:0:2::2")
+
+		Expect(hostVars).ToNot(HaveKey("ansible_password"))
+		Expect(hostVars).To(HaveKeyWithValue("ansible_port", float64(22)))
+		Expect(hostVars).To(HaveKeyWithValue("ansible_ssh_private_key_file", filepath.Join(workDir, "id_private")))
+		Expect(hostVars["ansible_ssh_common_args"]).To(HavePrefix("-o StrictHostKeyChecking=no -o UserKnownHostsFile="))
+
+		knownHosts, err := os.ReadFile(filepath.Join(workDir, "known_hosts"))
+		Expect(err).Should(Succeed())
+		Expect(knownHosts).To(BeEmpty())
+	})
+
+	It("rejects ssh secret without private key", func() {
+		_, err := execute.NewPlaybookRunner(newExecAttributes(map[string]string{
+			"type":                             "ssh",
+			"user":                             "fedora",
+			"password":                         "my secret password",
+			"disable-strict-host-key-checking": "true",
+		}), "vm", "- hosts: all", "")
+		Expect(err).To(MatchError("playbook requires the ssh-privatekey secret attribute, password authentication over ssh is not supported"))
+	})
+
+	DescribeTable("rejects other secret types", func(secret map[string]string) {
+		_, err := execute.NewPlaybookRunner(newExecAttributes(secret), "vm", "- hosts: all", "")
+		Expect(err).To(MatchError("playbook is supported only by the ssh secret type"))
+	},
+		Entry("winrm", map[string]string{"type": "winrm", "user": "Administrator", "password": "secret"}),
+		Entry("guest agent", map[string]string{"type": "guest-agent"}),
+	)
+
+	It("parses task results", func() {
+		results, err := execute.ParsePlaybookOutput([]byte(playbookOutput))
+		Expect(err).Should(Succeed())
+		Expect(results).To(Equal([]execute.PlaybookTaskResult{
+			{Play: "customize", Task: "Gathering Facts", Status: "ok"},
+			{Play: "customize", Task: "install packages", Status: "changed"},
+			{Play: "customize", Task: "configure windows", Status: "skipped"},
+			{Play: "customize", Task: "start service", Status: "failed", Message: "service not found"},
+		}))
+	})
+
+	It("reports invalid output", func() {
+		_, err := execute.ParsePlaybookOutput([]byte("ERROR! the playbook could not be found"))
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("runs the playbook", func() {
+		binDir, err := filepath.Abs(path.Join(workDir, "bin"))
+		Expect(err).Should(Succeed())
+		Expect(os.MkdirAll(binDir, testDirMode)).Should(Succeed())
+		fakeAnsible := "#!/bin/sh\ntest -f \"$2\" || exit 5\ngrep -q hosts \"$3\" || exit 6\ncat <<'EOF'\n" + playbookOutput + "\nEOF\nexit 2\n"
+		Expect(os.WriteFile(path.Join(binDir, "ansible-playbook"), []byte(fakeAnsible), 0755)).Should(Succeed())
+		originalPath := os.Getenv("PATH")
+		Expect(os.Setenv("PATH", binDir+":"+originalPath)).Should(Succeed())
+		DeferCleanup(os.Setenv, "PATH", originalPath)
+
+		runner, err := execute.NewPlaybookRunner(newExecAttributes(map[string]string{
+			"type":            "ssh",
+			"user":            "fedora",
+			"ssh-privatekey":  SSHTestPrivateKey,
+			"host-public-key": SSHTestPublicKey,
+		}), "vm", "- hosts: all\n  tasks: []\n", "")
+		Expect(err).Should(Succeed())
+
+		var stdout bytes.Buffer
+		results, err := runner.Run("10.0.0.5", 0, &stdout, GinkgoWriter)
+		Expect(err).To(Equal(exit.Exit{Code: 2, Soft: true}))
+		Expect(results).To(HaveLen(4))
+		Expect(stdout.String()).To(Equal("ok: [customize] Gathering Facts\n" +
+			"changed: [customize] install packages\n" +
+			"skipped: [customize] configure windows\n" +
+			"failed: [customize] start service: service not found\n"))
+	})
+})
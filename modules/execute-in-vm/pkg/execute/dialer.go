@@ -0,0 +1,100 @@
+package execute
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
+	"go.uber.org/zap"
+	"kubevirt.io/client-go/kubecli"
+)
+
+// dialer opens TCP connections to the VM
+type dialer interface {
+	Dial(address string, timeout time.Duration) (net.Conn, error)
+}
+
+type netDialer struct{}
+
+func newNetDialer() *netDialer {
+	return &netDialer{}
+}
+
+func (d *netDialer) Dial(address string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("tcp", address, timeout)
+}
+
+// portForwarder is implemented by kubecli.VirtualMachineInstanceInterface
+type portForwarder interface {
+	PortForward(name string, port int, protocol string) (kubecli.StreamInterface, error)
+}
+
+// portForwardDialer tunnels connections through the portforward subresource of the VMI,
+// so the VM can be reached without connectivity to the pod network.
+// Only the port of the dialed address is used.
+type portForwardDialer struct {
+	portForwarder portForwarder
+	vmiName       string
+}
+
+func newPortForwardDialer(portForwarder portForwarder, vmiName string) *portForwardDialer {
+	return &portForwardDialer{portForwarder: portForwarder, vmiName: vmiName}
+}
+
+func (d *portForwardDialer) Dial(address string, timeout time.Duration) (net.Conn, error) {
+	_, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %v: %v", portStr, err.Error())
+	}
+
+	type portForwardResult struct {
+		stream kubecli.StreamInterface
+		err    error
+	}
+	resultChan := make(chan portForwardResult, 1)
+	go func() {
+		stream, err := d.portForwarder.PortForward(d.vmiName, port, "tcp")
+		resultChan <- portForwardResult{stream, err}
+	}()
+
+	var timeoutChan <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutChan = timer.C
+	}
+
+	select {
+	case result := <-resultChan:
+		if result.err != nil {
+			return nil, result.err
+		}
+		return streamAsConn(result.stream), nil
+	case <-timeoutChan:
+		go func() {
+			// release the tunnel if it is opened after the timeout
+			if result := <-resultChan; result.err == nil {
+				_ = streamAsConn(result.stream).Close()
+			}
+		}()
+		return nil, newConnectionError(fmt.Errorf("port forward to %v:%v timed out", d.vmiName, port))
+	}
+}
+
+// streamAsConn pipes the stream to a connection. The stream is closed together with the connection.
+func streamAsConn(stream kubecli.StreamInterface) net.Conn {
+	conn, streamConn := net.Pipe()
+	go func() {
+		defer streamConn.Close()
+		if err := stream.Stream(kubecli.StreamOptions{In: streamConn, Out: streamConn}); err != nil {
+			log.Logger().Debug("port forward stream closed", zap.Error(err))
+		}
+	}()
+	return conn
+}
@@ -0,0 +1,161 @@
+package execute_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execattributes"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execute"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utilstest"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	. "github.com/kubevirt/kubevirt-tekton-tasks/modules/sharedtest/testconstants"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"kubevirt.io/client-go/kubecli"
+)
+
+// tcpStream streams a TCP connection like the portforward subresource streams the VMI port
+type tcpStream struct {
+	conn net.Conn
+}
+
+func (s *tcpStream) Stream(options kubecli.StreamOptions) error {
+	defer s.conn.Close()
+	copyErr := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(s.conn, options.In)
+		copyErr <- err
+	}()
+	go func() {
+		_, err := io.Copy(options.Out, s.conn)
+		copyErr <- err
+	}()
+	return <-copyErr
+}
+
+func (s *tcpStream) AsConn() net.Conn {
+	return s.conn
+}
+
+// fakePortForwarder forwards ports of all VMIs to localhost
+type fakePortForwarder struct {
+	err   error
+	delay time.Duration
+
+	lock     sync.Mutex
+	forwards []string
+}
+
+func (f *fakePortForwarder) PortForward(name string, port int, protocol string) (kubecli.StreamInterface, error) {
+	f.lock.Lock()
+	f.forwards = append(f.forwards, fmt.Sprintf("%v/%v/%v", name, port, protocol))
+	f.lock.Unlock()
+
+	time.Sleep(f.delay)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	conn, err := net.Dial("tcp", net.JoinHostPort(localhost, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	return &tcpStream{conn: conn}, nil
+}
+
+func (f *fakePortForwarder) getForwards() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]string{}, f.forwards...)
+}
+
+var _ = Describe("PortForwardDialer", func() {
+	var testSecretPath string
+	var server *utilstest.SSHServer
+	var port int
+	var portForwarder *fakePortForwarder
+
+	BeforeEach(func() {
+		testSecretPath = path.Join(testPath, TestRandomName("port-forward-secret"))
+		Expect(os.MkdirAll(testSecretPath, testDirMode)).Should(Succeed())
+
+		server = utilstest.NewSSHServer(utilstest.SSHServerOptions{
+			User:           "fedora",
+			HostPrivateKey: SSHTestPrivateKey2,
+			AuthorizedKey:  SSHTestPublicKey,
+			Handler:        testCommandHandler,
+		})
+		port = server.Start()
+		portForwarder = &fakePortForwarder{}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(testSecretPath)).Should(Succeed())
+	})
+
+	newExecutor := func() execute.RemoteExecutor {
+		PrepareTestSecret(testSecretPath, map[string]string{
+			"type":                   "ssh",
+			"user":                   "fedora",
+			"ssh-privatekey":         SSHTestPrivateKey,
+			"host-public-key":        SSHTestPublicKey2,
+			"additional-ssh-options": fmt.Sprintf("-p %v", port),
+		})
+		attributes := execattributes.NewExecAttributes()
+		Expect(attributes.Init(testSecretPath)).Should(Succeed())
+
+		executor := execute.NewSSHExecutor(attributes, execute.NewPortForwardDialer(portForwarder, "my-vm"))
+		Expect(executor.Init("my-vm")).Should(Succeed())
+		return executor
+	}
+
+	It("executes remote commands through the tunnel", func() {
+		var stdout bytes.Buffer
+		executor := newExecutor()
+		Expect(executor.TestConnection()).To(BeTrue())
+
+		Expect(executor.RemoteExecute("echo hello", 0, &stdout, GinkgoWriter)).To(Equal(exit.Exit{Code: 0, Soft: true}))
+		Expect(stdout.String()).To(Equal("hello"))
+		Expect(server.GetCommands()).To(Equal([]string{"echo hello"}))
+
+		forward := fmt.Sprintf("my-vm/%v/tcp", port)
+		Expect(portForwarder.getForwards()).To(Equal([]string{forward, forward}))
+	})
+
+	It("fails when the port cannot be forwarded", func() {
+		portForwarder.err = errors.New("Virtual Machine not found.")
+		executor := newExecutor()
+		Expect(executor.TestConnection()).To(BeFalse())
+
+		err := executor.RemoteExecute("echo hello", 0, GinkgoWriter, GinkgoWriter)
+		Expect(err).To(MatchError("Virtual Machine not found."))
+	})
+
+	It("times out", func() {
+		portForwarder.delay = 3 * time.Second
+		dialer := execute.NewPortForwardDialer(portForwarder, "my-vm")
+
+		start := time.Now()
+		conn, err := dialer.Dial("my-vm:22", 100*time.Millisecond)
+		Expect(conn).To(BeNil())
+		Expect(err).To(MatchError("port forward to my-vm:22 timed out"))
+		Expect(time.Since(start)).Should(BeNumerically("<", 3*time.Second))
+	})
+
+	It("rejects invalid addresses", func() {
+		dialer := execute.NewPortForwardDialer(portForwarder, "my-vm")
+
+		_, err := dialer.Dial("my-vm", 0)
+		Expect(err).Should(HaveOccurred())
+		Expect(portForwarder.getForwards()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,42 @@
+package execute_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utilstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	testPath     = "./unit-test-tmp/"
+	testFileMode = 0666
+	testDirMode  = 0777
+)
+
+func TestExecute(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Execute Suite")
+}
+
+var _ = BeforeSuite(func() {
+	utilstest.SetupTestSuite()
+
+	err := os.MkdirAll(testPath, testDirMode)
+	Expect(err).Should(Succeed())
+})
+var _ = AfterSuite(func() {
+	utilstest.TearDownSuite()
+	err := os.RemoveAll(testPath)
+	Expect(err).Should(Succeed())
+})
+
+func PrepareTestSecret(basePath string, setup map[string]string) {
+	for filename, content := range setup {
+		err := os.WriteFile(path.Join(basePath, filename), []byte(content), testFileMode)
+		Expect(err).Should(Succeed())
+	}
+}
@@ -1,16 +1,26 @@
 package execute
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execattributes"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/report"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/parse"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/vmi"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	res "github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/results"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zerrors"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -23,18 +33,52 @@ type Executor struct {
 	clioptions     *parse.CLIOptions
 	kubevirtClient kubecli.KubevirtClient
 	executor       RemoteExecutor
+	playbook       *playbookRunner
+	readiness      *readinessChecker
+	retry          *retryPolicy
+	report         *report.VirtualMachineReport
+
+	shutdownCommand string
+	connected       bool
 
 	attemptedStart  bool
 	attemptedStop   bool
 	attemptedDelete bool
 	ipAddress       string
+
+	uploadChecksums   []string
+	downloadChecksums []string
+	stdout            *boundedOutput
+	stderr            *boundedOutput
+	exitCode          *int
+	attempts          int
+	playbookTasks     []playbookTaskResult
+	outputPrefix      string
+
+	snapshotName  string
+	snapshotReady bool
+	restoreName   string
 }
 
 func NewExecutor(clioptions *parse.CLIOptions, connectionSecretPath string) (*Executor, error) {
 	var executor RemoteExecutor
 
-	executor = newSSHExecutor(clioptions, execattributes.NewExecAttributes())
-	if clioptions.GetScript() != "" {
+	kubevirtClient, err := newKubevirtClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var vmDialer dialer = newNetDialer()
+	if clioptions.ShouldPortForward() {
+		vmDialer = newPortForwardDialer(kubevirtClient.VirtualMachineInstance(clioptions.GetVirtualMachineNamespace()), clioptions.VirtualMachineName)
+	}
+
+	guestAgent := newVirtLauncherGuestAgentClient(kubevirtClient, clioptions.VirtualMachineName, clioptions.GetVirtualMachineNamespace())
+
+	var playbook *playbookRunner
+	var shutdownCommand string
+	executor = newSSHExecutor(execattributes.NewExecAttributes(), vmDialer)
+	if clioptions.HasRemoteActions() {
 		execAttributes := execattributes.NewExecAttributes()
 
 		if err := execAttributes.Init(connectionSecretPath); err != nil {
@@ -44,12 +88,43 @@ func NewExecutor(clioptions *parse.CLIOptions, connectionSecretPath string) (*Ex
 
 		switch execAttributes.GetType() {
 		case constants.SSHSecretType:
-			executor = newSSHExecutor(clioptions, execAttributes)
+			executor = newSSHExecutor(execAttributes, vmDialer)
+		case constants.WinRMSecretType:
+			executor = newWinRMExecutor(execAttributes, vmDialer)
+		case constants.GuestAgentSecretType:
+			executor = newGuestAgentExecutor(guestAgent, execAttributes.GetGuestAgentAttributes().GetShell())
 		default:
 			return nil, fmt.Errorf("invalid secret/execution type %v", execAttributes.GetType())
 		}
+
+		if shutdownCommand = clioptions.GetShutdownCommand(); shutdownCommand == "" {
+			shutdownCommand = getDefaultShutdownCommand(execAttributes.GetType())
+		}
+
+		if clioptions.HasPlaybook() {
+			if playbook, err = newPlaybookRunner(execAttributes, clioptions.VirtualMachineName, clioptions.GetPlaybook(), clioptions.GetPlaybookFile()); err != nil {
+				return nil, err
+			}
+		}
 	}
 
+	readiness := newReadinessChecker(executor, guestAgent, clioptions.GetReadinessProbe())
+	if err := readiness.Validate(clioptions.GetReadinessGates()); err != nil {
+		return nil, err
+	}
+
+	return &Executor{
+		clioptions:      clioptions,
+		kubevirtClient:  kubevirtClient,
+		executor:        executor,
+		playbook:        playbook,
+		readiness:       readiness,
+		retry:           newRetryPolicy(clioptions),
+		shutdownCommand: shutdownCommand,
+	}, nil
+}
+
+func newKubevirtClient() (kubecli.KubevirtClient, error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		return nil, err
@@ -59,8 +134,17 @@ func NewExecutor(clioptions *parse.CLIOptions, connectionSecretPath string) (*Ex
 	if err != nil {
 		return nil, fmt.Errorf("%v: %v", "cannot create kubevirt client", err.Error())
 	}
+	return kubevirtClient, nil
+}
 
-	return &Executor{clioptions: clioptions, kubevirtClient: kubevirtClient, executor: executor}, nil
+// SetReport sets the report to record the events of the VM lifecycle to
+func (e *Executor) SetReport(vmReport *report.VirtualMachineReport) {
+	e.report = vmReport
+}
+
+// SetOutputPrefix prefixes each line of the script output printed to the standard streams
+func (e *Executor) SetOutputPrefix(prefix string) {
+	e.outputPrefix = prefix
 }
 
 func (e *Executor) EnsureVMRunning(timeout time.Duration) error {
@@ -98,7 +182,18 @@ func (e *Executor) EnsureVMRunning(timeout time.Duration) error {
 			log.Logger().Debug("waiting for a VMI to recover", logFields...)
 			return false, nil
 		case kubevirtv1.Running:
-			ipAddress, ipError := vmi.GetPodIPAddress(vmInstance)
+			e.report.AddEvent(report.VMRunningEvent)
+			if !e.executor.RequiresIPAddress() {
+				return true, nil
+			}
+
+			if e.clioptions.ShouldPortForward() {
+				// the port forward dialer connects to the VMI by its name
+				e.ipAddress = vmName
+				return true, nil
+			}
+
+			ipAddress, ipError := e.getIPAddress(vmInstance)
 
 			if ipAddress == "" || ipError != nil {
 				log.Logger().Debug("ip address not found", logFields[0], logFields[1], zap.Reflect("status", vmInstance.Status))
@@ -111,6 +206,7 @@ func (e *Executor) EnsureVMRunning(timeout time.Duration) error {
 			}
 			log.Logger().Debug("ip address found", zap.String("ipAddress", ipAddress))
 			e.ipAddress = ipAddress
+			e.report.AddEvent(report.IPAddressFoundEvent)
 
 			return true, nil
 
@@ -127,46 +223,31 @@ func (e *Executor) EnsureVMRunning(timeout time.Duration) error {
 
 }
 
-func (e *Executor) EnsureVMStopped() error {
-	vmName := e.clioptions.VirtualMachineName
-	vmNamespace := e.clioptions.GetVirtualMachineNamespace()
+func (e *Executor) getIPAddress(vmInstance *kubevirtv1.VirtualMachineInstance) (string, error) {
+	ipFamily := e.clioptions.GetIPFamily()
 
-	return wait.PollImmediateInfinite(constants.PollVMItoStopInterval, func() (bool, error) {
-		vmi, err := e.kubevirtClient.VirtualMachineInstance(vmNamespace).Get(vmName, &v1.GetOptions{})
-
-		if err == nil {
-			switch vmi.Status.Phase {
-			case kubevirtv1.Succeeded, kubevirtv1.Failed:
-				return true, nil
-			}
-
-			if stopErr := e.ensureVMStop(); stopErr != nil {
-				switch t := stopErr.(type) {
-				case *errors.StatusError:
-					if t.Status().Reason == v1.StatusReasonConflict { // stop already requested
-						return false, nil
-					}
-					return false, stopErr
-				default:
-					return false, stopErr
-				}
-			}
+	if serviceName := e.clioptions.GetService(); serviceName != "" {
+		service, err := e.kubevirtClient.CoreV1().Services(vmInstance.Namespace).Get(context.TODO(), serviceName, v1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
 
-			log.Logger().Debug("waiting for a VM to stop", zap.String("name", vmName), zap.String("namespace", vmNamespace))
-			return false, nil
+		clusterIPs := service.Spec.ClusterIPs
+		if len(clusterIPs) == 0 && service.Spec.ClusterIP != "" {
+			clusterIPs = []string{service.Spec.ClusterIP}
+		}
+		if len(clusterIPs) == 0 || clusterIPs[0] == corev1.ClusterIPNone {
+			return "", fmt.Errorf("service %v has no cluster IP", serviceName)
 		}
 
-		switch t := err.(type) {
-		case *errors.StatusError:
-			if t.Status().Reason == v1.StatusReasonNotFound {
-				return true, nil
-			}
-			return false, err
-		default:
-			return false, err
+		ipAddress := vmi.SelectIPAddress(clusterIPs, ipFamily)
+		if ipAddress == "" {
+			return "", fmt.Errorf("service %v has no %v cluster IP", serviceName, ipFamily)
 		}
+		return ipAddress, nil
+	}
 
-	})
+	return vmi.GetIPAddress(vmInstance, e.clioptions.GetNetwork(), ipFamily)
 }
 
 func (e *Executor) EnsureVMDeleted() error {
@@ -197,7 +278,20 @@ func (e *Executor) EnsureVMDeleted() error {
 	})
 }
 
+// SetupConnection waits until the VM accepts connections and the readiness gates pass.
+// Connection errors are retried according to the retry policy.
 func (e *Executor) SetupConnection(timeout time.Duration) error {
+	_, err := e.retry.Run("SetupConnection", timeout, func(_ int, timeout time.Duration) error {
+		return e.setupConnection(timeout)
+	})
+	if err == nil {
+		e.connected = true
+		e.report.AddEvent(report.ConnectionReadyEvent)
+	}
+	return err
+}
+
+func (e *Executor) setupConnection(timeout time.Duration) error {
 	if e.executor == nil {
 		return fmt.Errorf("executor is missing or was not initialized")
 	}
@@ -206,6 +300,7 @@ func (e *Executor) SetupConnection(timeout time.Duration) error {
 		return err
 	}
 
+	start := time.Now()
 	conditionFn := func() (done bool, err error) {
 		return e.executor.TestConnection(), nil
 	}
@@ -217,14 +312,186 @@ func (e *Executor) SetupConnection(timeout time.Duration) error {
 		err = wait.PollImmediate(constants.PollValidConnectionInterval, timeout, conditionFn)
 	}
 	time.Sleep(constants.SetupConnectionDelay)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if gates := e.clioptions.GetReadinessGates(); len(gates) > 0 {
+		remainingTimeout := time.Duration(0)
+		if timeout > 0 {
+			if remainingTimeout = timeout - time.Since(start); remainingTimeout <= 0 {
+				return wait.ErrWaitTimeout
+			}
+		}
+		return e.readiness.WaitFor(gates, remainingTimeout)
+	}
+
+	return nil
 }
 
+// RemoteExecute executes the script or runs the playbook until it succeeds or fails with an error which should not be retried.
+// The connection is set up again before retrying a connection error.
 func (e *Executor) RemoteExecute(timeout time.Duration) error {
+	var lastErr error
+	attempts, err := e.retry.Run("RemoteExecute", timeout, func(attempt int, timeout time.Duration) error {
+		if attempt > 1 && isConnectionError(lastErr) {
+			setupStart := time.Now()
+			if err := e.setupConnection(timeout); err != nil {
+				return err
+			}
+			if timeout > 0 {
+				if timeout -= time.Since(setupStart); timeout <= 0 {
+					return wait.ErrWaitTimeout
+				}
+			}
+		}
+
+		log.Logger().Debug("executing script", zap.Int("attempt", attempt), zap.Bool("playbook", e.playbook != nil))
+		lastErr = e.remoteExecute(timeout)
+		return lastErr
+	})
+	e.attempts = attempts
+	return err
+}
+
+func (e *Executor) remoteExecute(timeout time.Duration) error {
 	if e.executor == nil {
 		return fmt.Errorf("executor is missing or was not initialized")
 	}
-	return e.executor.RemoteExecute(timeout)
+
+	// only the last attempt is recorded
+	e.exitCode = nil
+	e.playbookTasks = nil
+	e.stdout = newBoundedOutput(constants.OutputResultMaxSize)
+	e.stderr = newBoundedOutput(constants.OutputResultMaxSize)
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	if e.outputPrefix != "" {
+		prefixedStdout := newPrefixedOutput(os.Stdout, e.outputPrefix)
+		prefixedStderr := newPrefixedOutput(os.Stderr, e.outputPrefix)
+		defer prefixedStdout.Flush()
+		defer prefixedStderr.Flush()
+		stdout, stderr = prefixedStdout, prefixedStderr
+	}
+	stdoutWriters := []io.Writer{stdout, e.stdout}
+	stderrWriters := []io.Writer{stderr, e.stderr}
+
+	for _, outputFile := range []struct {
+		path    string
+		writers *[]io.Writer
+	}{
+		{e.clioptions.GetStdoutFile(), &stdoutWriters},
+		{e.clioptions.GetStderrFile(), &stderrWriters},
+	} {
+		if outputFile.path == "" {
+			continue
+		}
+		file, err := createOutputFile(outputFile.path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		*outputFile.writers = append(*outputFile.writers, file)
+	}
+
+	var err error
+	if e.playbook != nil {
+		e.playbookTasks, err = e.playbook.Run(e.ipAddress, timeout, io.MultiWriter(stdoutWriters...), io.MultiWriter(stderrWriters...))
+	} else {
+		err = e.executor.RemoteExecute(e.clioptions.GetScript(), timeout, io.MultiWriter(stdoutWriters...), io.MultiWriter(stderrWriters...))
+	}
+	if exitErr, ok := err.(exit.Exit); ok {
+		e.exitCode = &exitErr.Code
+	}
+	return err
+}
+
+func (e *Executor) UploadFiles(timeout time.Duration) error {
+	transferer, err := e.getFileTransferer()
+	if err != nil {
+		return err
+	}
+
+	checksums, err := transferer.UploadFiles(e.clioptions.GetUploads(), timeout)
+	e.uploadChecksums = formatChecksums(e.clioptions.GetUploads(), checksums)
+	return err
+}
+
+func (e *Executor) DownloadFiles(timeout time.Duration) error {
+	transferer, err := e.getFileTransferer()
+	if err != nil {
+		return err
+	}
+
+	checksums, err := transferer.DownloadFiles(e.clioptions.GetDownloads(), timeout)
+	e.downloadChecksums = formatChecksums(e.clioptions.GetDownloads(), checksums)
+	return err
+}
+
+// RecordResults records the output of the script if it was executed and checksums of the transferred files
+// if any transfers were requested
+func (e *Executor) RecordResults() error {
+	results := map[string]string{}
+
+	if e.stdout != nil && e.stderr != nil {
+		results[constants.StdoutResultName] = e.stdout.String()
+		results[constants.StderrResultName] = e.stderr.String()
+	}
+
+	if e.exitCode != nil {
+		results[constants.ExitCodeResultName] = strconv.Itoa(*e.exitCode)
+	}
+
+	if e.attempts > 0 {
+		results[constants.AttemptsResultName] = strconv.Itoa(e.attempts)
+	}
+
+	if e.playbook != nil && e.stdout != nil {
+		playbookTasks := newBoundedOutput(constants.OutputResultMaxSize)
+		for _, task := range e.playbookTasks {
+			_, _ = fmt.Fprintln(playbookTasks, task.String())
+		}
+		results[constants.PlaybookTasksResultName] = playbookTasks.String()
+	}
+
+	if len(e.clioptions.GetUploads()) > 0 {
+		results[constants.UploadChecksumsResultName] = strings.Join(e.uploadChecksums, "\n")
+	}
+
+	if len(e.clioptions.GetDownloads()) > 0 {
+		results[constants.DownloadChecksumsResultName] = strings.Join(e.downloadChecksums, "\n")
+	}
+
+	// do not log results, the output could contain secrets
+	log.Logger().Debug("recording results")
+	return res.RecordResults(results)
+}
+
+func (e *Executor) getFileTransferer() (FileTransferer, error) {
+	if e.executor == nil {
+		return nil, fmt.Errorf("executor is missing or was not initialized")
+	}
+
+	transferer, ok := e.executor.(FileTransferer)
+	if !ok {
+		return nil, fmt.Errorf("file transfers are supported only by the %v secret type", constants.SSHSecretType)
+	}
+	return transferer, nil
+}
+
+// formatChecksums formats checksums in the sha256sum output format with the destination paths
+func formatChecksums(files []parse.FileTransfer, checksums []string) []string {
+	var result []string
+	for idx, checksum := range checksums {
+		result = append(result, fmt.Sprintf("%v  %v", checksum, files[idx].Destination))
+	}
+	return result
+}
+
+func createOutputFile(filePath string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return nil, err
+	}
+	return os.Create(filePath)
 }
 
 func (e *Executor) ensureVMStarted() error {
@@ -236,25 +503,11 @@ func (e *Executor) ensureVMStarted() error {
 		if err := e.kubevirtClient.VirtualMachine(vmNamespace).Start(vmName, &kubevirtv1.StartOptions{}); err != nil {
 			return err
 		}
+		e.report.AddEvent(report.VMStartRequestedEvent)
 	}
 	return nil
 }
 
-func (e *Executor) ensureVMStop() error {
-	vmName := e.clioptions.VirtualMachineName
-	vmNamespace := e.clioptions.GetVirtualMachineNamespace()
-	if !e.attemptedStop {
-		e.attemptedStop = true
-
-		log.Logger().Debug("stopping a vm", zap.String("name", vmName), zap.String("namespace", vmNamespace))
-		if err := e.kubevirtClient.VirtualMachine(vmNamespace).Stop(vmName, &kubevirtv1.StopOptions{}); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
 func (e *Executor) ensureVMDelete() error {
 	vmName := e.clioptions.VirtualMachineName
 	vmNamespace := e.clioptions.GetVirtualMachineNamespace()
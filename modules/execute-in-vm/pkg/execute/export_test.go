@@ -0,0 +1,34 @@
+package execute
+
+import "time"
+
+var NewSSHExecutor = newSSHExecutor
+var NewWinRMExecutor = newWinRMExecutor
+var NewGuestAgentExecutor = newGuestAgentExecutor
+var NewBoundedOutput = newBoundedOutput
+var NewNetDialer = newNetDialer
+var NewPortForwardDialer = newPortForwardDialer
+var NewReadinessChecker = newReadinessChecker
+var NewRetryPolicy = newRetryPolicy
+var IsConnectionError = isConnectionError
+var NewConnectionError = newConnectionError
+var IsSnapshotReady = isSnapshotReady
+var IsRestoreComplete = isRestoreComplete
+var NewPrefixedOutput = newPrefixedOutput
+var NewDetachableOutput = newDetachableOutput
+var NewPlaybookRunner = newPlaybookRunner
+var ParsePlaybookOutput = parsePlaybookOutput
+
+type PlaybookTaskResult = playbookTaskResult
+
+func (p *retryPolicy) GetDelay(attempt int) time.Duration {
+	return p.getDelay(attempt)
+}
+
+func (r *playbookRunner) GenerateInventory(ipAddress, workDir string) ([]byte, error) {
+	return r.generateInventory(ipAddress, workDir)
+}
+
+var GetDefaultShutdownCommand = getDefaultShutdownCommand
+var IsConflict = isConflict
+var IsShutdownCommandFailure = isShutdownCommandFailure
@@ -0,0 +1,77 @@
+package execute
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/parse"
+	res "github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/results"
+	"go.uber.org/zap"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetVirtualMachineNames returns the names of the VMs set by the options or of the VMs matching the label selector
+func GetVirtualMachineNames(clioptions *parse.CLIOptions) ([]string, error) {
+	if selector := clioptions.GetVirtualMachineSelector(); selector != "" {
+		kubevirtClient, err := newKubevirtClient()
+		if err != nil {
+			return nil, err
+		}
+
+		vmList, err := kubevirtClient.VirtualMachine(clioptions.GetVirtualMachineNamespace()).List(&v1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+
+		var vmNames []string
+		for _, vm := range vmList.Items {
+			vmNames = append(vmNames, vm.Name)
+		}
+		sort.Strings(vmNames)
+		log.Logger().Debug("selected vms", zap.String("selector", selector), zap.Strings("names", vmNames))
+		return vmNames, nil
+	}
+
+	if vmNames := clioptions.GetVirtualMachineNames(); len(vmNames) > 0 {
+		return vmNames, nil
+	}
+
+	return []string{clioptions.VirtualMachineName}, nil
+}
+
+// RunConcurrently calls fn for each VM with at most concurrency calls running at the same time.
+// The errors are returned in the order of vmNames.
+func RunConcurrently(vmNames []string, concurrency int, fn func(vmName string) error) []error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	errs := make([]error, len(vmNames))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for idx, vmName := range vmNames {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(idx int, vmName string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			errs[idx] = fn(vmName)
+		}(idx, vmName)
+	}
+
+	wg.Wait()
+	return errs
+}
+
+// RecordFleetResults records the names of the VMs which passed and failed
+func RecordFleetResults(passedVMNames, failedVMNames []string) error {
+	log.Logger().Debug("recording results", zap.Strings("passed", passedVMNames), zap.Strings("failed", failedVMNames))
+	return res.RecordResults(map[string]string{
+		constants.PassedVMsResultName: strings.Join(passedVMNames, "\n"),
+		constants.FailedVMsResultName: strings.Join(failedVMNames, "\n"),
+	})
+}
@@ -0,0 +1,70 @@
+package execute_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execute"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/parse"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fleet", func() {
+	It("returns errors in the order of the vms", func() {
+		errs := execute.RunConcurrently([]string{"vm1", "vm2", "vm3"}, 3, func(vmName string) error {
+			if vmName == "vm2" {
+				time.Sleep(50 * time.Millisecond)
+				return errors.New("vm2 failed")
+			}
+			return nil
+		})
+		Expect(errs).To(HaveLen(3))
+		Expect(errs[0]).To(BeNil())
+		Expect(errs[1]).To(MatchError("vm2 failed"))
+		Expect(errs[2]).To(BeNil())
+	})
+
+	DescribeTable("limits the concurrency", func(concurrency, expectedMaxRunning int) {
+		var lock sync.Mutex
+		running, maxRunning := 0, 0
+
+		errs := execute.RunConcurrently([]string{"vm1", "vm2", "vm3", "vm4", "vm5"}, concurrency, func(_ string) error {
+			lock.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			lock.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			lock.Lock()
+			running--
+			lock.Unlock()
+			return nil
+		})
+		Expect(errs).To(HaveLen(5))
+		Expect(maxRunning).To(Equal(expectedMaxRunning))
+	},
+		Entry("sequentially", 1, 1),
+		Entry("two at the same time", 2, 2),
+		Entry("all at the same time", 10, 5),
+		Entry("invalid concurrency", 0, 1),
+	)
+
+	It("returns vm names set by options", func() {
+		options := &parse.CLIOptions{
+			VirtualMachineNames:     "vm1,vm2",
+			VirtualMachineNamespace: "default",
+			Script:                  "exit 0",
+			ConnectionSecretName:    "my-secret",
+		}
+		Expect(options.Init()).Should(Succeed())
+
+		vmNames, err := execute.GetVirtualMachineNames(options)
+		Expect(err).Should(Succeed())
+		Expect(vmNames).To(Equal([]string{"vm1", "vm2"}))
+	})
+})
@@ -0,0 +1,134 @@
+package execute
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/kubecli"
+)
+
+const virtLauncherComputeContainer = "compute"
+
+// guestAgentRoleExample is the opt-in role which allows the guest-agent execution type to exec into virt-launcher pods
+const guestAgentRoleExample = "examples/roles/guest-agent-role.yaml"
+
+type guestAgentClient interface {
+	IsAgentConnected() (bool, error)
+	// GetGuestOSInfo returns the guest OS reported by the guest agent
+	GetGuestOSInfo() (*kubevirtv1.VirtualMachineInstanceGuestOSInfo, error)
+	// Command sends a raw QEMU guest agent command and returns the raw response
+	Command(command string) (string, error)
+}
+
+// virtLauncherGuestAgentClient reads the guest agent state through the KubeVirt API. KubeVirt does not expose
+// arbitrary guest agent commands, so they are passed to libvirt running in the virt-launcher pod of the VMI.
+// Executing the commands requires the opt-in guestAgentRoleExample role.
+type virtLauncherGuestAgentClient struct {
+	kubevirtClient kubecli.KubevirtClient
+	name           string
+	namespace      string
+}
+
+func newVirtLauncherGuestAgentClient(kubevirtClient kubecli.KubevirtClient, name, namespace string) *virtLauncherGuestAgentClient {
+	return &virtLauncherGuestAgentClient{kubevirtClient: kubevirtClient, name: name, namespace: namespace}
+}
+
+func (c *virtLauncherGuestAgentClient) IsAgentConnected() (bool, error) {
+	vmInstance, err := c.kubevirtClient.VirtualMachineInstance(c.namespace).Get(c.name, &v1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	for _, condition := range vmInstance.Status.Conditions {
+		if condition.Type == kubevirtv1.VirtualMachineInstanceAgentConnected {
+			return condition.Status == corev1.ConditionTrue, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *virtLauncherGuestAgentClient) GetGuestOSInfo() (*kubevirtv1.VirtualMachineInstanceGuestOSInfo, error) {
+	guestAgentInfo, err := c.kubevirtClient.VirtualMachineInstance(c.namespace).GuestOsInfo(c.name)
+	if err != nil {
+		return nil, err
+	}
+	return &guestAgentInfo.OS, nil
+}
+
+func (c *virtLauncherGuestAgentClient) Command(command string) (string, error) {
+	vmInstance, err := c.kubevirtClient.VirtualMachineInstance(c.namespace).Get(c.name, &v1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	podName, err := c.getVirtLauncherPodName(vmInstance)
+	if err != nil {
+		return "", err
+	}
+
+	domainName := fmt.Sprintf("%v_%v", c.namespace, c.name)
+	request := c.kubevirtClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(c.namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: virtLauncherComputeContainer,
+			Command:   []string{"virsh", "qemu-agent-command", domainName, command},
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	log.Logger().Debug("executing guest agent command in virt-launcher pod", zap.String("pod", podName))
+	executor, err := remotecommand.NewSPDYExecutor(c.kubevirtClient.Config(), "POST", request.URL())
+	if err != nil {
+		return "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := executor.Stream(remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr}); err != nil {
+		if errors.IsForbidden(err) {
+			return "", forbiddenGuestAgentCommandError(err)
+		}
+		if stderr.Len() > 0 {
+			return "", fmt.Errorf("%v: %v", err.Error(), strings.TrimSpace(stderr.String()))
+		}
+		return "", err
+	}
+
+	return stdout.String(), nil
+}
+
+func (c *virtLauncherGuestAgentClient) getVirtLauncherPodName(vmInstance *kubevirtv1.VirtualMachineInstance) (string, error) {
+	pods, err := c.kubevirtClient.CoreV1().Pods(c.namespace).List(context.TODO(), v1.ListOptions{
+		LabelSelector: fmt.Sprintf("%v=%v", kubevirtv1.CreatedByLabel, vmInstance.UID),
+	})
+	if err != nil {
+		if errors.IsForbidden(err) {
+			return "", forbiddenGuestAgentCommandError(err)
+		}
+		return "", err
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			log.Logger().Debug("found virt-launcher pod", zap.String("pod", pod.Name))
+			return pod.Name, nil
+		}
+	}
+	return "", fmt.Errorf("running virt-launcher pod of %v VMI not found", c.name)
+}
+
+func forbiddenGuestAgentCommandError(err error) error {
+	return fmt.Errorf("%v: the guest-agent secret type requires the opt-in role from %v to exec into the virt-launcher pod", err.Error(), guestAgentRoleExample)
+}
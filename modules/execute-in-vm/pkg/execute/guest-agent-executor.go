@@ -0,0 +1,269 @@
+package execute
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zconstants/connectionsecret"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zerrors"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// windowsGuestOSID is the guest OS ID reported by the guest agent of Windows guests
+const windowsGuestOSID = "mswindows"
+
+// commands passed to the shell to kill a timed out process, because the guest agent cannot terminate processes
+const (
+	posixKillCommand   = "kill -9 %v"
+	windowsKillCommand = "taskkill /F /T /PID %v"
+)
+
+type guestAgentRequest struct {
+	Execute   string      `json:"execute"`
+	Arguments interface{} `json:"arguments,omitempty"`
+}
+
+type guestAgentResponse struct {
+	Return json.RawMessage `json:"return"`
+	Error  *struct {
+		Class string `json:"class"`
+		Desc  string `json:"desc"`
+	} `json:"error"`
+}
+
+type guestExecArguments struct {
+	Path          string   `json:"path"`
+	Arg           []string `json:"arg,omitempty"`
+	CaptureOutput bool     `json:"capture-output"`
+}
+
+type guestExecResult struct {
+	PID int `json:"pid"`
+}
+
+type guestExecStatusArguments struct {
+	PID int `json:"pid"`
+}
+
+type guestExecStatusResult struct {
+	Exited       bool   `json:"exited"`
+	ExitCode     *int   `json:"exitcode"`
+	Signal       *int   `json:"signal"`
+	OutData      string `json:"out-data"`
+	ErrData      string `json:"err-data"`
+	OutTruncated bool   `json:"out-truncated"`
+	ErrTruncated bool   `json:"err-truncated"`
+}
+
+type guestAgentExecutor struct {
+	client guestAgentClient
+	// shell the script is passed to as the last argument; resolved from the guest OS if empty
+	shell []string
+	// guestOSID is resolved on the first use
+	guestOSID *string
+}
+
+func newGuestAgentExecutor(client guestAgentClient, shell []string) *guestAgentExecutor {
+	return &guestAgentExecutor{client: client, shell: shell}
+}
+
+func (e *guestAgentExecutor) Init(_ string) error {
+	return nil
+}
+
+func (e *guestAgentExecutor) RequiresIPAddress() bool {
+	return false
+}
+
+func (e *guestAgentExecutor) TestConnection() bool {
+	connected, err := e.client.IsAgentConnected()
+	if err != nil {
+		log.Logger().Debug("could not check guest agent connection", zap.Error(err))
+		return false
+	}
+	if !connected {
+		log.Logger().Debug("guest agent is not connected")
+	}
+	return connected
+}
+
+func (e *guestAgentExecutor) RemoteExecute(script string, timeout time.Duration, stdout, stderr io.Writer) error {
+	shell, err := e.getShell()
+	if err != nil {
+		return err
+	}
+	log.Logger().Debug("executing guest agent command", zap.Strings("shell", shell))
+
+	var execResult guestExecResult
+	// do not log script
+	if err := e.command("guest-exec", guestExecArguments{
+		Path:          shell[0],
+		Arg:           append(append([]string{}, shell[1:]...), script),
+		CaptureOutput: true,
+	}, &execResult); err != nil {
+		return err
+	}
+	log.Logger().Debug("guest agent command started", zap.Int("pid", execResult.PID))
+
+	var timeoutChan <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutChan = timer.C
+	}
+
+	for {
+		var status guestExecStatusResult
+		if err := e.command("guest-exec-status", guestExecStatusArguments{PID: execResult.PID}, &status); err != nil {
+			return err
+		}
+
+		if status.Exited {
+			return handleGuestExecStatus(&status, stdout, stderr)
+		}
+
+		select {
+		case <-timeoutChan:
+			e.killProcess(shell, execResult.PID)
+			return exit.Exit{
+				Code: constants.CommandTimeout,
+				Msg:  "command timed out",
+				Soft: true,
+			}
+		case <-time.After(constants.PollGuestAgentExecStatusInterval):
+		}
+	}
+}
+
+// getShell returns the configured shell or the default POSIX shell. Windows guests have to configure the shell.
+func (e *guestAgentExecutor) getShell() ([]string, error) {
+	if len(e.shell) > 0 {
+		return e.shell, nil
+	}
+
+	isWindows, err := e.isWindowsGuest()
+	if err != nil {
+		return nil, err
+	}
+	if isWindows {
+		return nil, zerrors.NewMissingRequiredError("%v secret attribute is required for Windows guests, e.g. powershell.exe -Command",
+			connectionsecret.GuestAgentConnectionSecretKeys.Shell)
+	}
+
+	e.shell = strings.Fields(constants.DefaultGuestAgentShell)
+	return e.shell, nil
+}
+
+func (e *guestAgentExecutor) isWindowsGuest() (bool, error) {
+	if e.guestOSID == nil {
+		osInfo, err := e.client.GetGuestOSInfo()
+		if err != nil {
+			return false, fmt.Errorf("could not get guest OS info: %v", err.Error())
+		}
+		e.guestOSID = &osInfo.ID
+	}
+	return *e.guestOSID == windowsGuestOSID, nil
+}
+
+// killProcess kills the timed out process with the shell and waits until it exits
+func (e *guestAgentExecutor) killProcess(shell []string, pid int) {
+	logFields := []zap.Field{zap.Int("pid", pid)}
+
+	killCommand := posixKillCommand
+	if isWindows, err := e.isWindowsGuest(); err != nil {
+		log.Logger().Warn("could not kill the timed out guest agent command, it may still be running in the guest", append(logFields, zap.Error(err))...)
+		return
+	} else if isWindows {
+		killCommand = windowsKillCommand
+	}
+
+	var killResult guestExecResult
+	if err := e.command("guest-exec", guestExecArguments{
+		Path:          shell[0],
+		Arg:           append(append([]string{}, shell[1:]...), fmt.Sprintf(killCommand, pid)),
+		CaptureOutput: true,
+	}, &killResult); err != nil {
+		log.Logger().Warn("could not kill the timed out guest agent command, it may still be running in the guest", append(logFields, zap.Error(err))...)
+		return
+	}
+
+	err := wait.PollImmediate(constants.PollGuestAgentExecStatusInterval, constants.GuestAgentKillTimeout, func() (bool, error) {
+		var status guestExecStatusResult
+		if err := e.command("guest-exec-status", guestExecStatusArguments{PID: pid}, &status); err != nil {
+			return false, err
+		}
+		return status.Exited, nil
+	})
+	if err != nil {
+		log.Logger().Warn("timed out guest agent command did not exit after it was killed and may still be running in the guest", append(logFields, zap.Error(err))...)
+		return
+	}
+	log.Logger().Debug("timed out guest agent command was killed", logFields...)
+}
+
+func (e *guestAgentExecutor) command(execute string, arguments interface{}, result interface{}) error {
+	request, err := json.Marshal(guestAgentRequest{Execute: execute, Arguments: arguments})
+	if err != nil {
+		return err
+	}
+
+	rawResponse, err := e.client.Command(string(request))
+	if err != nil {
+		return err
+	}
+
+	var response guestAgentResponse
+	if err := json.Unmarshal([]byte(rawResponse), &response); err != nil {
+		return fmt.Errorf("invalid %v response: %v", execute, err.Error())
+	}
+
+	if response.Error != nil {
+		return fmt.Errorf("%v failed: %v: %v", execute, response.Error.Class, response.Error.Desc)
+	}
+
+	return json.Unmarshal(response.Return, result)
+}
+
+func handleGuestExecStatus(status *guestExecStatusResult, stdout, stderr io.Writer) error {
+	for _, stream := range []struct {
+		name      string
+		data      string
+		truncated bool
+		output    io.Writer
+	}{
+		{"stdout", status.OutData, status.OutTruncated, stdout},
+		{"stderr", status.ErrData, status.ErrTruncated, stderr},
+	} {
+		data, err := base64.StdEncoding.DecodeString(stream.data)
+		if err != nil {
+			return err
+		}
+		if _, err := stream.output.Write(data); err != nil {
+			return err
+		}
+		if stream.truncated {
+			log.Logger().Debug("guest agent command output was truncated", zap.String("stream", stream.name))
+		}
+	}
+
+	exitCode := 0
+	if status.ExitCode != nil {
+		exitCode = *status.ExitCode
+	} else if status.Signal != nil {
+		// same as shells report processes terminated by a signal
+		exitCode = 128 + *status.Signal
+	}
+
+	return exit.Exit{
+		Code: exitCode,
+		Soft: true,
+	}
+}
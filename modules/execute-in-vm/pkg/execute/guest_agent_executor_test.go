@@ -0,0 +1,242 @@
+package execute_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execute"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+type fakeGuestProcess struct {
+	stdout   bytes.Buffer
+	stderr   bytes.Buffer
+	exitCode *uint32
+	signals  chan string
+}
+
+var killCommandRegex = regexp.MustCompile(`^(?:kill -9|taskkill /F /T /PID) (\d+)$`)
+
+// fakeGuestAgent runs guest-exec commands with testCommandHandler and kills the processes with the kill commands
+type fakeGuestAgent struct {
+	connected bool
+	err       error
+	osID      string
+
+	lock      sync.Mutex
+	shells    [][]string
+	scripts   []string
+	processes map[int]*fakeGuestProcess
+}
+
+func newFakeGuestAgent() *fakeGuestAgent {
+	return &fakeGuestAgent{connected: true, osID: "fedora", processes: map[int]*fakeGuestProcess{}}
+}
+
+func (a *fakeGuestAgent) IsAgentConnected() (bool, error) {
+	return a.connected, a.err
+}
+
+func (a *fakeGuestAgent) GetGuestOSInfo() (*kubevirtv1.VirtualMachineInstanceGuestOSInfo, error) {
+	return &kubevirtv1.VirtualMachineInstanceGuestOSInfo{ID: a.osID}, a.err
+}
+
+func (a *fakeGuestAgent) Command(command string) (string, error) {
+	if a.err != nil {
+		return "", a.err
+	}
+
+	var request struct {
+		Execute   string `json:"execute"`
+		Arguments struct {
+			PID           int      `json:"pid"`
+			Path          string   `json:"path"`
+			Arg           []string `json:"arg"`
+			CaptureOutput bool     `json:"capture-output"`
+		} `json:"arguments"`
+	}
+	Expect(json.Unmarshal([]byte(command), &request)).Should(Succeed())
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	switch request.Execute {
+	case "guest-exec":
+		Expect(request.Arguments.Arg).ToNot(BeEmpty())
+		Expect(request.Arguments.CaptureOutput).To(BeTrue())
+
+		lastArg := len(request.Arguments.Arg) - 1
+		script := request.Arguments.Arg[lastArg]
+		pid := len(a.scripts) + 1000
+		process := &fakeGuestProcess{signals: make(chan string, 1)}
+		a.shells = append(a.shells, append([]string{request.Arguments.Path}, request.Arguments.Arg[:lastArg]...))
+		a.scripts = append(a.scripts, script)
+		a.processes[pid] = process
+
+		if match := killCommandRegex.FindStringSubmatch(script); match != nil {
+			killedPID, _ := strconv.Atoi(match[1])
+			if killed, ok := a.processes[killedPID]; ok {
+				killed.signals <- "KILL"
+			}
+			script = "exit 0"
+		}
+
+		go func() {
+			var stdout, stderr bytes.Buffer
+			exitCode := testCommandHandler(script, &stdout, &stderr, process.signals)
+
+			a.lock.Lock()
+			defer a.lock.Unlock()
+			process.stdout.Write(stdout.Bytes())
+			process.stderr.Write(stderr.Bytes())
+			process.exitCode = &exitCode
+		}()
+		return fmt.Sprintf(`{"return": {"pid": %v}}`, pid), nil
+	case "guest-exec-status":
+		process, ok := a.processes[request.Arguments.PID]
+		if !ok {
+			return `{"error": {"class": "GenericError", "desc": "Invalid parameter 'pid'"}}`, nil
+		}
+		if process.exitCode == nil {
+			return `{"return": {"exited": false}}`, nil
+		}
+		return fmt.Sprintf(`{"return": {"exited": true, "exitcode": %v, "out-data": "%v", "err-data": "%v"}}`,
+			*process.exitCode,
+			base64.StdEncoding.EncodeToString(process.stdout.Bytes()),
+			base64.StdEncoding.EncodeToString(process.stderr.Bytes()),
+		), nil
+	default:
+		return `{"error": {"class": "CommandNotFound", "desc": "The command has not been found"}}`, nil
+	}
+}
+
+func (a *fakeGuestAgent) getShells() [][]string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return append([][]string{}, a.shells...)
+}
+
+func (a *fakeGuestAgent) isExited(pid int) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	process, ok := a.processes[pid]
+	return ok && process.exitCode != nil
+}
+
+func (a *fakeGuestAgent) getScripts() []string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return append([]string{}, a.scripts...)
+}
+
+var _ = Describe("GuestAgentExecutor", func() {
+	var agent *fakeGuestAgent
+
+	BeforeEach(func() {
+		agent = newFakeGuestAgent()
+	})
+
+	newExecutor := func() execute.RemoteExecutor {
+		executor := execute.NewGuestAgentExecutor(agent, nil)
+		Expect(executor.Init("")).Should(Succeed())
+		return executor
+	}
+
+	It("does not require an IP address", func() {
+		Expect(newExecutor().RequiresIPAddress()).To(BeFalse())
+	})
+
+	DescribeTable("executes remote commands", func(script string, timeout time.Duration, expectedExitCode int) {
+		executor := newExecutor()
+		Expect(executor.TestConnection()).To(BeTrue())
+
+		err := executor.RemoteExecute(script, timeout, GinkgoWriter, GinkgoWriter)
+		Expect(err).To(Equal(exit.Exit{Code: expectedExitCode, Soft: true}))
+		Expect(agent.getScripts()).To(Equal([]string{script}))
+		Expect(agent.getShells()).To(Equal([][]string{{"/bin/sh", "-c"}}))
+	},
+		Entry("successful command", "echo hello", 0*time.Second, 0),
+		Entry("failing command", "exit 3", 0*time.Second, 3),
+		Entry("unknown command", "ls", 0*time.Second, 127),
+		Entry("command finishes before timeout", "sleep 1500ms", 5*time.Second, 0),
+	)
+
+	It("writes the command output", func() {
+		var stdout, stderr bytes.Buffer
+		executor := newExecutor()
+
+		Expect(executor.RemoteExecute("echo hello", 0, &stdout, &stderr)).To(Equal(exit.Exit{Code: 0, Soft: true}))
+		Expect(stdout.String()).To(Equal("hello"))
+		Expect(stderr.String()).To(BeEmpty())
+	})
+
+	It("executes the script with the configured shell", func() {
+		agent.osID = "mswindows"
+		executor := execute.NewGuestAgentExecutor(agent, []string{"powershell.exe", "-Command"})
+
+		Expect(executor.RemoteExecute("echo hello", 0, GinkgoWriter, GinkgoWriter)).To(Equal(exit.Exit{Code: 0, Soft: true}))
+		Expect(agent.getShells()).To(Equal([][]string{{"powershell.exe", "-Command"}}))
+		Expect(agent.getScripts()).To(Equal([]string{"echo hello"}))
+	})
+
+	It("requires the shell for Windows guests", func() {
+		agent.osID = "mswindows"
+
+		err := newExecutor().RemoteExecute("echo hello", 0, GinkgoWriter, GinkgoWriter)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).To(Equal("shell secret attribute is required for Windows guests, e.g. powershell.exe -Command"))
+		Expect(agent.getScripts()).To(BeEmpty())
+	})
+
+	It("times out", func() {
+		timeout := 200 * time.Millisecond
+		executor := newExecutor()
+
+		start := time.Now()
+		err := executor.RemoteExecute("sleep 10s", timeout, GinkgoWriter, GinkgoWriter)
+		Expect(err).To(Equal(exit.Exit{Code: constants.CommandTimeout, Msg: "command timed out", Soft: true}))
+		Expect(time.Since(start)).Should(BeNumerically(">=", timeout))
+		Expect(time.Since(start)).Should(BeNumerically("<", 10*time.Second))
+	})
+
+	DescribeTable("kills the command on timeout", func(osID string, shell []string, expectedKillCommand string) {
+		agent.osID = osID
+		executor := execute.NewGuestAgentExecutor(agent, shell)
+
+		err := executor.RemoteExecute("sleep 10s", 200*time.Millisecond, GinkgoWriter, GinkgoWriter)
+		Expect(err).To(Equal(exit.Exit{Code: constants.CommandTimeout, Msg: "command timed out", Soft: true}))
+		Expect(agent.getScripts()).To(Equal([]string{"sleep 10s", expectedKillCommand}))
+		Expect(agent.isExited(1000)).To(BeTrue())
+	},
+		Entry("linux guest", "fedora", nil, "kill -9 1000"),
+		Entry("windows guest", "mswindows", []string{"powershell.exe", "-Command"}, "taskkill /F /T /PID 1000"),
+	)
+
+	It("detects disconnected guest agent", func() {
+		agent.connected = false
+		Expect(newExecutor().TestConnection()).To(BeFalse())
+
+		agent.connected = true
+		agent.err = errors.New("vmi not found")
+		Expect(newExecutor().TestConnection()).To(BeFalse())
+	})
+
+	It("fails on client error", func() {
+		agent.err = errors.New("virt-launcher pod not found")
+
+		err := newExecutor().RemoteExecute("exit 0", 0, GinkgoWriter, GinkgoWriter)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("virt-launcher pod not found"))
+	})
+})
@@ -0,0 +1,149 @@
+package execute
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"unicode/utf8"
+)
+
+const truncatedOutputMarker = "[truncated]\n"
+
+// boundedOutput keeps the last bytes of the written output which fit into the limit.
+// The output is prefixed with a truncation marker when older bytes were dropped.
+type boundedOutput struct {
+	limit     int
+	lock      sync.Mutex
+	data      []byte
+	truncated bool
+}
+
+func newBoundedOutput(limit int) *boundedOutput {
+	return &boundedOutput{limit: limit}
+}
+
+func (o *boundedOutput) Write(p []byte) (int, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.data = append(o.data, p...)
+
+	if len(o.data) > o.limit {
+		o.truncated = true
+		// keep more than needed to not reallocate on every write
+		if len(o.data) > 2*o.limit {
+			o.data = append([]byte{}, o.data[len(o.data)-o.limit:]...)
+		}
+	}
+
+	return len(p), nil
+}
+
+func (o *boundedOutput) String() string {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if !o.truncated {
+		return string(o.data)
+	}
+
+	keep := o.limit - len(truncatedOutputMarker)
+	if keep <= 0 {
+		return truncatedOutputMarker[:o.limit]
+	}
+
+	start := len(o.data) - keep
+	// do not split multi-byte characters
+	for start < len(o.data) && !utf8.RuneStart(o.data[start]) {
+		start++
+	}
+
+	return truncatedOutputMarker + string(o.data[start:])
+}
+
+// outputLock serializes lines of prefixed outputs written concurrently to the same stream
+var outputLock sync.Mutex
+
+// prefixedOutput prefixes each line written to out, e.g. to distinguish outputs of VMs executing the script concurrently.
+// Incomplete lines are buffered until they are terminated or flushed.
+type prefixedOutput struct {
+	out    io.Writer
+	prefix string
+	lock   sync.Mutex
+	buffer []byte
+}
+
+func newPrefixedOutput(out io.Writer, prefix string) *prefixedOutput {
+	return &prefixedOutput{out: out, prefix: prefix}
+}
+
+func (o *prefixedOutput) Write(p []byte) (int, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.buffer = append(o.buffer, p...)
+
+	for {
+		idx := bytes.IndexByte(o.buffer, '\n')
+		if idx < 0 {
+			break
+		}
+		if err := o.writeLine(o.buffer[:idx+1]); err != nil {
+			return 0, err
+		}
+		o.buffer = o.buffer[idx+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes the buffered incomplete line
+func (o *prefixedOutput) Flush() error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if len(o.buffer) == 0 {
+		return nil
+	}
+
+	err := o.writeLine(append(o.buffer, '\n'))
+	o.buffer = nil
+	return err
+}
+
+func (o *prefixedOutput) writeLine(line []byte) error {
+	outputLock.Lock()
+	defer outputLock.Unlock()
+
+	_, err := o.out.Write(append([]byte(o.prefix), line...))
+	return err
+}
+
+// detachableOutput forwards the written output until it is detached. Writes after detaching are dropped,
+// so output copied by goroutines which outlive a command is not written after the command returns.
+type detachableOutput struct {
+	lock   sync.Mutex
+	writer io.Writer
+}
+
+func newDetachableOutput(writer io.Writer) *detachableOutput {
+	return &detachableOutput{writer: writer}
+}
+
+func (o *detachableOutput) Write(p []byte) (int, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if o.writer == nil {
+		return len(p), nil
+	}
+	return o.writer.Write(p)
+}
+
+// Detach waits for the running write to finish and drops all next writes
+func (o *detachableOutput) Detach() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.writer = nil
+}
@@ -0,0 +1,63 @@
+package execute_test
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execute"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Output", func() {
+	DescribeTable("keeps the end of the output", func(limit int, writes []string, expectedOutput string) {
+		output := execute.NewBoundedOutput(limit)
+		for _, write := range writes {
+			n, err := output.Write([]byte(write))
+			Expect(err).Should(Succeed())
+			Expect(n).To(Equal(len(write)))
+		}
+		Expect(output.String()).To(Equal(expectedOutput))
+		Expect(len(output.String())).To(BeNumerically("<=", limit))
+	},
+		Entry("empty output", 20, nil, ""),
+		Entry("output within limit", 20, []string{"hello ", "world"}, "hello world"),
+		Entry("output at limit", 11, []string{"hello ", "world"}, "hello world"),
+		Entry("truncated output", 20, []string{"first line\n", "second line\n", "third line\n"}, "[truncated]\nrd line\n"),
+		Entry("truncated large write", 20, []string{strings.Repeat("a", 100) + "end"}, "[truncated]\naaaaaend"),
+		Entry("does not split characters", 15, []string{"aaaaaaaaaaaa", "čč"}, "[truncated]\nč"),
+		Entry("limit smaller than marker", 5, []string{"hello world"}, "[trun"),
+	)
+
+	DescribeTable("prefixes each line", func(writes []string, expectedOutput string) {
+		out := &bytes.Buffer{}
+		output := execute.NewPrefixedOutput(out, "[vm] ")
+		for _, write := range writes {
+			n, err := output.Write([]byte(write))
+			Expect(err).Should(Succeed())
+			Expect(n).To(Equal(len(write)))
+		}
+		Expect(output.Flush()).Should(Succeed())
+		Expect(out.String()).To(Equal(expectedOutput))
+	},
+		Entry("empty output", nil, ""),
+		Entry("single line", []string{"hello world\n"}, "[vm] hello world\n"),
+		Entry("multiple lines", []string{"first\nsecond\n"}, "[vm] first\n[vm] second\n"),
+		Entry("line split across writes", []string{"hel", "lo\nwor", "ld\n"}, "[vm] hello\n[vm] world\n"),
+		Entry("unterminated line", []string{"first\nsecond"}, "[vm] first\n[vm] second\n"),
+		Entry("empty lines", []string{"\n\n"}, "[vm] \n[vm] \n"),
+	)
+
+	It("drops writes after detaching", func() {
+		out := &bytes.Buffer{}
+		output := execute.NewDetachableOutput(out)
+
+		_, err := output.Write([]byte("kept"))
+		Expect(err).Should(Succeed())
+		output.Detach()
+		n, err := output.Write([]byte("dropped"))
+		Expect(err).Should(Succeed())
+		Expect(n).To(Equal(len("dropped")))
+		Expect(out.String()).To(Equal("kept"))
+	})
+})
@@ -0,0 +1,96 @@
+package execute
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/parse"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// readinessChecker waits for the guest to be ready to execute the script after it accepts connections
+type readinessChecker struct {
+	executor   RemoteExecutor
+	guestAgent guestAgentClient
+	probe      string
+}
+
+func newReadinessChecker(executor RemoteExecutor, guestAgent guestAgentClient, probe string) *readinessChecker {
+	return &readinessChecker{executor: executor, guestAgent: guestAgent, probe: probe}
+}
+
+func (r *readinessChecker) Validate(gates []parse.ReadinessGate) error {
+	for _, gate := range gates {
+		if gate.Type == constants.AuthReadinessGate {
+			if _, ok := r.executor.(Authenticator); !ok {
+				return fmt.Errorf("%v readiness gate is supported only by the ssh and winrm secret types", gate.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// WaitFor waits for the gates in order. Gates without a timeout are bounded by the timeout.
+func (r *readinessChecker) WaitFor(gates []parse.ReadinessGate, timeout time.Duration) error {
+	for _, gate := range gates {
+		gateType := gate.Type
+		gateTimeout := gate.Timeout
+		if gateTimeout <= 0 {
+			gateTimeout = timeout
+		}
+		log.Logger().Debug("waiting for readiness gate", zap.String("gate", string(gateType)), zap.Duration("timeout", gateTimeout))
+
+		conditionFn := func() (bool, error) {
+			return r.isReady(gateType)
+		}
+
+		var err error
+		if gateTimeout <= 0 {
+			err = wait.PollImmediateInfinite(constants.PollReadinessGateInterval, conditionFn)
+		} else {
+			err = wait.PollImmediate(constants.PollReadinessGateInterval, gateTimeout, conditionFn)
+		}
+		if err != nil {
+			return fmt.Errorf("%v readiness gate: %w", gateType, err)
+		}
+		log.Logger().Debug("readiness gate passed", zap.String("gate", string(gateType)))
+	}
+	return nil
+}
+
+func (r *readinessChecker) isReady(gateType constants.ReadinessGateType) (bool, error) {
+	switch gateType {
+	case constants.GuestAgentReadinessGate:
+		connected, err := r.guestAgent.IsAgentConnected()
+		if err != nil {
+			log.Logger().Debug("could not check guest agent connection", zap.Error(err))
+			return false, nil
+		}
+		return connected, nil
+	case constants.AuthReadinessGate:
+		authenticator, ok := r.executor.(Authenticator)
+		if !ok {
+			return false, fmt.Errorf("authentication cannot be tested")
+		}
+		if err := authenticator.TestAuthentication(); err != nil {
+			log.Logger().Debug("authentication failed", zap.Error(err))
+			return false, nil
+		}
+		return true, nil
+	case constants.ProbeReadinessGate:
+		// do not log probe
+		err := r.executor.RemoteExecute(r.probe, constants.ReadinessProbeTimeout, io.Discard, io.Discard)
+		if exitErr, ok := err.(exit.Exit); ok && exitErr.Code == 0 {
+			return true, nil
+		}
+		log.Logger().Debug("readiness probe failed", zap.Error(err))
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown readiness gate %v", gateType)
+	}
+}
@@ -0,0 +1,108 @@
+package execute_test
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execattributes"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execute"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/parse"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utilstest"
+	. "github.com/kubevirt/kubevirt-tekton-tasks/modules/sharedtest/testconstants"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var _ = Describe("ReadinessChecker", func() {
+	var testSecretPath string
+	var server *utilstest.SSHServer
+	var agent *fakeGuestAgent
+
+	BeforeEach(func() {
+		testSecretPath = path.Join(testPath, TestRandomName("readiness-secret"))
+		Expect(os.MkdirAll(testSecretPath, testDirMode)).Should(Succeed())
+
+		server = utilstest.NewSSHServer(utilstest.SSHServerOptions{
+			User:           "fedora",
+			HostPrivateKey: SSHTestPrivateKey2,
+			AuthorizedKey:  SSHTestPublicKey,
+			Handler:        testCommandHandler,
+		})
+		agent = newFakeGuestAgent()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(testSecretPath)).Should(Succeed())
+	})
+
+	newSSHExecutor := func(privateKey string) execute.RemoteExecutor {
+		port := server.Start()
+		PrepareTestSecret(testSecretPath, map[string]string{
+			"type":                   "ssh",
+			"user":                   "fedora",
+			"ssh-privatekey":         privateKey,
+			"host-public-key":        SSHTestPublicKey2,
+			"additional-ssh-options": fmt.Sprintf("-p %v", port),
+		})
+		attributes := execattributes.NewExecAttributes()
+		Expect(attributes.Init(testSecretPath)).Should(Succeed())
+
+		executor := execute.NewSSHExecutor(attributes, execute.NewNetDialer())
+		Expect(executor.Init(localhost)).Should(Succeed())
+		return executor
+	}
+
+	DescribeTable("waits for readiness gates", func(gateType constants.ReadinessGateType, probe string, agentConnected bool, privateKey string, shouldSucceed bool) {
+		agent.connected = agentConnected
+		checker := execute.NewReadinessChecker(newSSHExecutor(privateKey), agent, probe)
+
+		gates := []parse.ReadinessGate{{Type: gateType, Timeout: 100 * time.Millisecond}}
+		Expect(checker.Validate(gates)).Should(Succeed())
+
+		err := checker.WaitFor(gates, 0)
+		if shouldSucceed {
+			Expect(err).Should(Succeed())
+		} else {
+			Expect(err).To(MatchError(fmt.Sprintf("%v readiness gate: %v", gateType, wait.ErrWaitTimeout.Error())))
+		}
+	},
+		Entry("connected guest agent", constants.GuestAgentReadinessGate, "", true, SSHTestPrivateKey, true),
+		Entry("disconnected guest agent", constants.GuestAgentReadinessGate, "", false, SSHTestPrivateKey, false),
+		Entry("successful authentication", constants.AuthReadinessGate, "", false, SSHTestPrivateKey, true),
+		Entry("failed authentication", constants.AuthReadinessGate, "", false, SSHTestPrivateKey2, false),
+		Entry("successful probe", constants.ProbeReadinessGate, "echo ready", false, SSHTestPrivateKey, true),
+		Entry("failing probe", constants.ProbeReadinessGate, "exit 1", false, SSHTestPrivateKey, false),
+	)
+
+	It("runs the probe in the guest", func() {
+		checker := execute.NewReadinessChecker(newSSHExecutor(SSHTestPrivateKey), agent, "echo ready")
+
+		Expect(checker.WaitFor([]parse.ReadinessGate{{Type: constants.ProbeReadinessGate}}, time.Second)).Should(Succeed())
+		Expect(server.GetCommands()).To(Equal([]string{"echo ready"}))
+	})
+
+	It("waits for gates in order", func() {
+		agent.connected = false
+		checker := execute.NewReadinessChecker(newSSHExecutor(SSHTestPrivateKey), agent, "echo ready")
+
+		err := checker.WaitFor([]parse.ReadinessGate{
+			{Type: constants.GuestAgentReadinessGate},
+			{Type: constants.ProbeReadinessGate},
+		}, 100*time.Millisecond)
+		Expect(err).To(MatchError(fmt.Sprintf("guest-agent readiness gate: %v", wait.ErrWaitTimeout.Error())))
+		Expect(err).To(MatchError(wait.ErrWaitTimeout))
+		Expect(server.GetCommands()).To(BeEmpty())
+	})
+
+	It("rejects auth gate for guest agent executor", func() {
+		checker := execute.NewReadinessChecker(execute.NewGuestAgentExecutor(agent, nil), agent, "")
+
+		err := checker.Validate([]parse.ReadinessGate{{Type: constants.AuthReadinessGate}})
+		Expect(err).To(MatchError("auth readiness gate is supported only by the ssh and winrm secret types"))
+	})
+})
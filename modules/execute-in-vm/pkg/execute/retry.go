@@ -0,0 +1,161 @@
+package execute
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/parse"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+type retryPolicy struct {
+	maxAttempts      int
+	backoff          time.Duration
+	exitCodes        map[int]bool
+	connectionErrors bool
+}
+
+func newRetryPolicy(clioptions *parse.CLIOptions) *retryPolicy {
+	exitCodes := map[int]bool{}
+	for _, exitCode := range clioptions.GetRetryOnExitCodes() {
+		exitCodes[exitCode] = true
+	}
+
+	return &retryPolicy{
+		maxAttempts:      clioptions.GetMaxAttempts(),
+		backoff:          clioptions.GetRetryBackoff(),
+		exitCodes:        exitCodes,
+		connectionErrors: clioptions.ShouldRetryOnConnectionErrors(),
+	}
+}
+
+// Run calls fn until it succeeds, fails with an error which should not be retried, or maxAttempts is reached.
+// Each attempt gets the remaining timeout. The number of attempts is returned.
+func (p *retryPolicy) Run(name string, timeout time.Duration, fn func(attempt int, timeout time.Duration) error) (int, error) {
+	start := time.Now()
+	remainingTimeout := timeout
+
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt, remainingTimeout)
+		if isSuccess(err) || attempt >= p.maxAttempts || !p.isRetryable(err) {
+			return attempt, err
+		}
+
+		logFields := []zap.Field{zap.String("name", name), zap.Int("attempt", attempt), zap.Int("maxAttempts", p.maxAttempts)}
+		if exitErr, ok := err.(exit.Exit); ok {
+			logFields = append(logFields, zap.Int("exitCode", exitErr.Code))
+		} else {
+			logFields = append(logFields, zap.Error(err))
+		}
+
+		delay := p.getDelay(attempt)
+		if timeout > 0 {
+			if remainingTimeout = timeout - time.Since(start) - delay; remainingTimeout <= 0 {
+				log.Logger().Info("attempt failed, not enough time left to retry", logFields...)
+				return attempt, err
+			}
+		}
+
+		log.Logger().Info("attempt failed, retrying", append(logFields, zap.Duration("delay", delay))...)
+		time.Sleep(delay)
+	}
+}
+
+// getDelay returns the backoff doubled for each previous attempt and clamped to MaxRetryBackoff
+func (p *retryPolicy) getDelay(attempt int) time.Duration {
+	delay := p.backoff
+	for i := 1; i < attempt && delay < constants.MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > constants.MaxRetryBackoff {
+		return constants.MaxRetryBackoff
+	}
+	return delay
+}
+
+func (p *retryPolicy) isRetryable(err error) bool {
+	switch t := err.(type) {
+	case exit.Exit:
+		return p.exitCodes[t.Code]
+	default:
+		return isConnectionError(err) && p.connectionErrors
+	}
+}
+
+func isSuccess(err error) bool {
+	exitErr, ok := err.(exit.Exit)
+	return err == nil || (ok && exitErr.Code == 0)
+}
+
+// connectionError marks a failed or lost connection to the VM which is not recognizable by its cause,
+// e.g. a failed ssh handshake or a timed out port forward
+type connectionError struct {
+	err error
+}
+
+func newConnectionError(err error) error {
+	return &connectionError{err: err}
+}
+
+func (e *connectionError) Error() string {
+	return e.err.Error()
+}
+
+func (e *connectionError) Unwrap() error {
+	return e.err
+}
+
+// isConnectionError returns true for errors of a failed or lost connection to the VM, which can be transient.
+// Errors caused by the script, by an exhausted timeout, by authentication or by a misconfiguration are not connection errors.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var connErr *connectionError
+	var opErr *net.OpError
+	var netErr net.Error
+	var exitMissingErr *ssh.ExitMissingError
+	switch {
+	case errors.As(err, &connErr), errors.As(err, &opErr), errors.As(err, &exitMissingErr):
+		return true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return true
+	}
+
+	for _, connectionErr := range []error{io.EOF, io.ErrUnexpectedEOF, net.ErrClosed, syscall.ECONNREFUSED, syscall.ECONNRESET, syscall.EPIPE} {
+		if errors.Is(err, connectionErr) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetExitError returns the exit error of the script. Timeouts, including wrapped ones, are converted to the CommandTimeout exit code.
+// Returns false if err is not caused by the script or by a timeout.
+func GetExitError(err error) (*exit.Exit, bool) {
+	if exitErr, ok := err.(exit.Exit); ok {
+		return &exitErr, true
+	}
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		msg := "command timed out"
+		if err != wait.ErrWaitTimeout {
+			msg = fmt.Sprintf("%v: %v", msg, err.Error())
+		}
+		return &exit.Exit{
+			Code: constants.CommandTimeout,
+			Msg:  msg,
+			Soft: true,
+		}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,174 @@
+package execute_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execute"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/parse"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zerrors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func newRetryOptions(maxAttempts, backoff, exitCodes, connectionErrors string) *parse.CLIOptions {
+	return &parse.CLIOptions{
+		VirtualMachineName:      "vm",
+		VirtualMachineNamespace: "default",
+		Script:                  "exit 0",
+		ConnectionSecretName:    "my-secret",
+		MaxAttempts:             maxAttempts,
+		RetryBackoff:            backoff,
+		RetryOnExitCodes:        exitCodes,
+		RetryOnConnectionErrors: connectionErrors,
+	}
+}
+
+var _ = Describe("RetryPolicy", func() {
+	connectionErr := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+	newOptions := func(maxAttempts, backoff, exitCodes, connectionErrors string) *parse.CLIOptions {
+		options := newRetryOptions(maxAttempts, backoff, exitCodes, connectionErrors)
+		Expect(options.Init()).Should(Succeed())
+		return options
+	}
+
+	DescribeTable("retries failed attempts", func(options *parse.CLIOptions, results []error, expectedAttempts int) {
+		Expect(options.Init()).Should(Succeed())
+		policy := execute.NewRetryPolicy(options)
+
+		attempts, err := policy.Run("test", 0, func(attempt int, _ time.Duration) error {
+			return results[attempt-1]
+		})
+		Expect(attempts).To(Equal(expectedAttempts))
+		if expectedErr := results[expectedAttempts-1]; expectedErr == nil {
+			Expect(err).To(BeNil())
+		} else {
+			Expect(err).To(Equal(expectedErr))
+		}
+	},
+		Entry("successful attempt", newRetryOptions("3", "1ms", "1", "true"), []error{exit.Exit{Code: 0, Soft: true}}, 1),
+		Entry("successful attempt without exit code", newRetryOptions("3", "1ms", "", "true"), []error{nil}, 1),
+		Entry("retryable exit code", newRetryOptions("3", "1ms", "1, 255", ""), []error{
+			exit.Exit{Code: 255, Soft: true}, exit.Exit{Code: 1, Soft: true}, exit.Exit{Code: 0, Soft: true},
+		}, 3),
+		Entry("other exit code", newRetryOptions("3", "1ms", "1", ""), []error{exit.Exit{Code: 2, Soft: true}}, 1),
+		Entry("max attempts reached", newRetryOptions("2", "1ms", "1", ""), []error{
+			exit.Exit{Code: 1, Soft: true}, exit.Exit{Code: 1, Soft: true},
+		}, 2),
+		Entry("connection error", newRetryOptions("3", "1ms", "", "true"), []error{connectionErr, exit.Exit{Code: 0, Soft: true}}, 2),
+		Entry("connection error without retries", newRetryOptions("3", "1ms", "", "false"), []error{connectionErr}, 1),
+		Entry("default policy", newRetryOptions("", "", "", ""), []error{exit.Exit{Code: 1, Soft: true}}, 1),
+		Entry("exhausted timeout", newRetryOptions("3", "1ms", "", "true"), []error{wait.ErrWaitTimeout}, 1),
+		Entry("exhausted readiness gate timeout", newRetryOptions("3", "1ms", "", "true"), []error{fmt.Errorf("probe readiness gate: %w", wait.ErrWaitTimeout)}, 1),
+		Entry("command timeout", newRetryOptions("3", "1ms", "-4", "true"), []error{
+			exit.Exit{Code: constants.CommandTimeout, Msg: "command timed out", Soft: true}, exit.Exit{Code: 0, Soft: true},
+		}, 2),
+	)
+
+	It("doubles the backoff", func() {
+		policy := execute.NewRetryPolicy(newOptions("3", "50ms", "", "true"))
+
+		start := time.Now()
+		attempts, err := policy.Run("test", 0, func(_ int, _ time.Duration) error {
+			return connectionErr
+		})
+		Expect(attempts).To(Equal(3))
+		Expect(err).To(Equal(connectionErr))
+		Expect(time.Since(start)).Should(BeNumerically(">=", 150*time.Millisecond))
+	})
+
+	DescribeTable("clamps the backoff", func(backoff string, attempt int, expectedDelay time.Duration) {
+		policy := execute.NewRetryPolicy(newOptions("100", backoff, "", "true"))
+		Expect(policy.GetDelay(attempt)).To(Equal(expectedDelay))
+	},
+		Entry("first retry", "10s", 1, 10*time.Second),
+		Entry("doubled backoff", "10s", 3, 40*time.Second),
+		Entry("max backoff", "10s", 7, 10*time.Minute),
+		Entry("overflowing backoff", "10s", 99, 10*time.Minute),
+		Entry("backoff greater than max", "1h", 1, 10*time.Minute),
+		Entry("no backoff", "0s", 99, time.Duration(0)),
+	)
+
+	It("passes the remaining timeout to attempts", func() {
+		policy := execute.NewRetryPolicy(newOptions("2", "50ms", "", "true"))
+
+		var timeouts []time.Duration
+		attempts, _ := policy.Run("test", time.Second, func(_ int, timeout time.Duration) error {
+			timeouts = append(timeouts, timeout)
+			return connectionErr
+		})
+		Expect(attempts).To(Equal(2))
+		Expect(timeouts[0]).To(Equal(time.Second))
+		Expect(timeouts[1]).Should(BeNumerically("<=", time.Second-50*time.Millisecond))
+	})
+
+	It("does not retry when the timeout would expire", func() {
+		policy := execute.NewRetryPolicy(newOptions("3", "1s", "", "true"))
+
+		attempts, err := policy.Run("test", 100*time.Millisecond, func(_ int, _ time.Duration) error {
+			return connectionErr
+		})
+		Expect(attempts).To(Equal(1))
+		Expect(err).To(Equal(connectionErr))
+	})
+
+	DescribeTable("returns exit errors", func(err error, expectedExitError *exit.Exit) {
+		exitError, ok := execute.GetExitError(err)
+		Expect(ok).To(Equal(expectedExitError != nil))
+		Expect(exitError).To(Equal(expectedExitError))
+	},
+		Entry("script exit code", exit.Exit{Code: 3, Soft: true}, &exit.Exit{Code: 3, Soft: true}),
+		Entry("timeout", wait.ErrWaitTimeout, &exit.Exit{Code: constants.CommandTimeout, Msg: "command timed out", Soft: true}),
+		Entry("readiness gate timeout", fmt.Errorf("guest-agent readiness gate: %w", wait.ErrWaitTimeout),
+			&exit.Exit{Code: constants.CommandTimeout, Msg: "command timed out: guest-agent readiness gate: timed out waiting for the condition", Soft: true}),
+		Entry("connection error", errors.New("connection refused"), nil),
+	)
+
+	DescribeTable("classifies connection errors", func(err error, expectedConnectionError bool) {
+		Expect(execute.IsConnectionError(err)).To(Equal(expectedConnectionError))
+	},
+		Entry("refused connection", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true),
+		Entry("reset connection", fmt.Errorf("could not execute: %w", syscall.ECONNRESET), true),
+		Entry("closed connection", io.EOF, true),
+		Entry("connection lost during the script", &ssh.ExitMissingError{}, true),
+		Entry("failed handshake", execute.NewConnectionError(errors.New("ssh: handshake failed: EOF")), true),
+		Entry("no error", nil, false),
+		Entry("script exit code", exit.Exit{Code: 1, Soft: true}, false),
+		Entry("timeout", wait.ErrWaitTimeout, false),
+		Entry("ssh authentication", errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none publickey], no supported methods remain"), false),
+		Entry("ssh host key mismatch", errors.New("ssh: handshake failed: knownhosts: key mismatch"), false),
+		Entry("output file", &os.PathError{Op: "open", Path: "/data/stdout.txt", Err: syscall.EACCES}, false),
+		Entry("missing ansible-playbook", &exec.Error{Name: "ansible-playbook", Err: exec.ErrNotFound}, false),
+		Entry("invalid playbook output", json.Unmarshal([]byte("ERROR!"), &struct{}{}), false),
+		Entry("missing executor", errors.New("executor is missing or was not initialized"), false),
+		Entry("missing attribute", zerrors.NewMissingRequiredError("shell secret attribute is required"), false),
+	)
+
+	It("does not retry authentication and local I/O errors", func() {
+		options := newOptions("3", "1ms", "", "true")
+		policy := execute.NewRetryPolicy(options)
+
+		for _, nonConnectionErr := range []error{
+			errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none publickey], no supported methods remain"),
+			&os.PathError{Op: "open", Path: "/data/stdout.txt", Err: syscall.EACCES},
+		} {
+			attempts, err := policy.Run("test", 0, func(int, time.Duration) error {
+				return nonConnectionErr
+			})
+			Expect(attempts).To(Equal(1))
+			Expect(err).To(Equal(nonConnectionErr))
+		}
+	})
+})
@@ -0,0 +1,85 @@
+package execute
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/parse"
+	"github.com/pkg/sftp"
+)
+
+const downloadedFileMode = 0644
+
+type sftpTransferFunc func(client *sftp.Client, file parse.FileTransfer) (string, error)
+
+func uploadSFTPFile(client *sftp.Client, file parse.FileTransfer) (string, error) {
+	source, err := os.Open(file.Source)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	sourceInfo, err := source.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	if err := client.MkdirAll(path.Dir(file.Destination)); err != nil {
+		return "", err
+	}
+
+	destination, err := client.OpenFile(file.Destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return "", err
+	}
+
+	checksum, err := copyWithChecksum(destination, source)
+	if closeErr := destination.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// keep scripts executable
+	if err := client.Chmod(file.Destination, sourceInfo.Mode().Perm()); err != nil {
+		return "", err
+	}
+
+	return checksum, nil
+}
+
+func downloadSFTPFile(client *sftp.Client, file parse.FileTransfer) (string, error) {
+	source, err := client.Open(file.Source)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	if err := os.MkdirAll(filepath.Dir(file.Destination), os.ModePerm); err != nil {
+		return "", err
+	}
+
+	destination, err := os.OpenFile(file.Destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, downloadedFileMode)
+	if err != nil {
+		return "", err
+	}
+
+	checksum, err := copyWithChecksum(destination, source)
+	if closeErr := destination.Close(); err == nil {
+		err = closeErr
+	}
+	return checksum, err
+}
+
+func copyWithChecksum(destination io.Writer, source io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(destination, io.TeeReader(source, hash)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
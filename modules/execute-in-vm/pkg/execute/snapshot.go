@@ -0,0 +1,179 @@
+package execute
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	snapshotv1alpha1 "kubevirt.io/api/snapshot/v1alpha1"
+)
+
+// TakeSnapshot creates a VirtualMachineSnapshot of the VM and waits until it is ready to use
+func (e *Executor) TakeSnapshot(timeout time.Duration) error {
+	vmName := e.clioptions.VirtualMachineName
+	vmNamespace := e.clioptions.GetVirtualMachineNamespace()
+
+	snapshot, err := e.kubevirtClient.VirtualMachineSnapshot(vmNamespace).Create(context.TODO(), &snapshotv1alpha1.VirtualMachineSnapshot{
+		ObjectMeta: v1.ObjectMeta{
+			GenerateName: vmName + "-snapshot-",
+		},
+		Spec: snapshotv1alpha1.VirtualMachineSnapshotSpec{
+			Source: newVMReference(vmName),
+		},
+	}, v1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	e.snapshotName = snapshot.Name
+	log.Logger().Debug("created vm snapshot", zap.String("name", e.snapshotName), zap.String("namespace", vmNamespace))
+
+	if err := pollSnapshotStatus(timeout, func() (bool, error) {
+		snapshot, err := e.kubevirtClient.VirtualMachineSnapshot(vmNamespace).Get(context.TODO(), e.snapshotName, v1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return isSnapshotReady(snapshot)
+	}); err != nil {
+		return err
+	}
+
+	e.snapshotReady = true
+	return nil
+}
+
+// HasSnapshot returns true if a snapshot was taken and can be restored
+func (e *Executor) HasSnapshot() bool {
+	return e.snapshotReady
+}
+
+// RestoreSnapshot stops the VM, restores the snapshot taken by TakeSnapshot and starts the VM again if start is true.
+// Fails if the restore does not complete before the timeout.
+func (e *Executor) RestoreSnapshot(start bool, timeout time.Duration) error {
+	if !e.snapshotReady {
+		return fmt.Errorf("snapshot was not taken")
+	}
+
+	vmName := e.clioptions.VirtualMachineName
+	vmNamespace := e.clioptions.GetVirtualMachineNamespace()
+
+	if err := e.EnsureVMStopped(); err != nil {
+		return err
+	}
+
+	restore, err := e.kubevirtClient.VirtualMachineRestore(vmNamespace).Create(context.TODO(), &snapshotv1alpha1.VirtualMachineRestore{
+		ObjectMeta: v1.ObjectMeta{
+			GenerateName: vmName + "-restore-",
+		},
+		Spec: snapshotv1alpha1.VirtualMachineRestoreSpec{
+			Target:                     newVMReference(vmName),
+			VirtualMachineSnapshotName: e.snapshotName,
+		},
+	}, v1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	e.restoreName = restore.Name
+	log.Logger().Debug("created vm restore", zap.String("name", e.restoreName), zap.String("namespace", vmNamespace))
+
+	if err := pollSnapshotStatus(timeout, func() (bool, error) {
+		restore, err := e.kubevirtClient.VirtualMachineRestore(vmNamespace).Get(context.TODO(), e.restoreName, v1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return isRestoreComplete(restore)
+	}); err != nil {
+		if err == wait.ErrWaitTimeout {
+			return fmt.Errorf("restore %v did not complete in %v", e.restoreName, timeout)
+		}
+		return err
+	}
+
+	if start {
+		log.Logger().Debug("starting a restored vm", zap.String("name", vmName), zap.String("namespace", vmNamespace))
+		if err := e.kubevirtClient.VirtualMachine(vmNamespace).Start(vmName, &kubevirtv1.StartOptions{}); err != nil {
+			return err
+		}
+		// the vm can be stopped again
+		e.attemptedStop = false
+	}
+
+	return nil
+}
+
+// DeleteSnapshot deletes the snapshot and the restore created by TakeSnapshot and RestoreSnapshot
+func (e *Executor) DeleteSnapshot() error {
+	vmNamespace := e.clioptions.GetVirtualMachineNamespace()
+
+	if e.restoreName != "" {
+		if err := e.kubevirtClient.VirtualMachineRestore(vmNamespace).Delete(context.TODO(), e.restoreName, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	if e.snapshotName != "" {
+		if err := e.kubevirtClient.VirtualMachineSnapshot(vmNamespace).Delete(context.TODO(), e.snapshotName, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isSnapshotReady(snapshot *snapshotv1alpha1.VirtualMachineSnapshot) (bool, error) {
+	if snapshot.Status == nil {
+		return false, nil
+	}
+
+	if snapshot.Status.Phase == snapshotv1alpha1.Failed {
+		message := "unknown error"
+		if snapshot.Status.Error != nil && snapshot.Status.Error.Message != nil {
+			message = *snapshot.Status.Error.Message
+		}
+		return false, fmt.Errorf("snapshot %v failed: %v", snapshot.Name, message)
+	}
+
+	return snapshot.Status.ReadyToUse != nil && *snapshot.Status.ReadyToUse, nil
+}
+
+func isRestoreComplete(restore *snapshotv1alpha1.VirtualMachineRestore) (bool, error) {
+	if restore.Status == nil {
+		return false, nil
+	}
+
+	for _, condition := range restore.Status.Conditions {
+		if condition.Type == snapshotv1alpha1.ConditionFailure && condition.Status == corev1.ConditionTrue {
+			message := condition.Reason
+			if condition.Message != "" {
+				message = strings.TrimPrefix(message+": "+condition.Message, ": ")
+			}
+			return false, fmt.Errorf("restore %v failed: %v", restore.Name, message)
+		}
+	}
+
+	return restore.Status.Complete != nil && *restore.Status.Complete, nil
+}
+
+func newVMReference(vmName string) corev1.TypedLocalObjectReference {
+	apiGroup := kubevirtv1.GroupVersion.Group
+	return corev1.TypedLocalObjectReference{
+		APIGroup: &apiGroup,
+		Kind:     "VirtualMachine",
+		Name:     vmName,
+	}
+}
+
+func pollSnapshotStatus(timeout time.Duration, conditionFn wait.ConditionFunc) error {
+	if timeout <= 0 {
+		return wait.PollImmediateInfinite(constants.PollSnapshotInterval, conditionFn)
+	}
+	return wait.PollImmediate(constants.PollSnapshotInterval, timeout, conditionFn)
+}
@@ -0,0 +1,90 @@
+package execute_test
+
+import (
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execute"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	snapshotv1alpha1 "kubevirt.io/api/snapshot/v1alpha1"
+)
+
+var _ = Describe("Snapshot", func() {
+	boolPtr := func(value bool) *bool {
+		return &value
+	}
+
+	stringPtr := func(value string) *string {
+		return &value
+	}
+
+	DescribeTable("checks snapshot status", func(status *snapshotv1alpha1.VirtualMachineSnapshotStatus, expectedReady bool, expectedErr string) {
+		ready, err := execute.IsSnapshotReady(&snapshotv1alpha1.VirtualMachineSnapshot{
+			ObjectMeta: v1.ObjectMeta{Name: "vm-snapshot-abcde"},
+			Status:     status,
+		})
+		Expect(ready).To(Equal(expectedReady))
+		if expectedErr == "" {
+			Expect(err).Should(Succeed())
+		} else {
+			Expect(err).To(MatchError(expectedErr))
+		}
+	},
+		Entry("no status", nil, false, ""),
+		Entry("in progress", &snapshotv1alpha1.VirtualMachineSnapshotStatus{
+			Phase:      snapshotv1alpha1.InProgress,
+			ReadyToUse: boolPtr(false),
+		}, false, ""),
+		Entry("ready", &snapshotv1alpha1.VirtualMachineSnapshotStatus{
+			Phase:      snapshotv1alpha1.Succeeded,
+			ReadyToUse: boolPtr(true),
+		}, true, ""),
+		Entry("failed", &snapshotv1alpha1.VirtualMachineSnapshotStatus{
+			Phase: snapshotv1alpha1.Failed,
+			Error: &snapshotv1alpha1.Error{Message: stringPtr("snapshot deadline exceeded")},
+		}, false, "snapshot vm-snapshot-abcde failed: snapshot deadline exceeded"),
+		Entry("failed without error", &snapshotv1alpha1.VirtualMachineSnapshotStatus{
+			Phase: snapshotv1alpha1.Failed,
+		}, false, "snapshot vm-snapshot-abcde failed: unknown error"),
+	)
+
+	DescribeTable("checks restore status", func(status *snapshotv1alpha1.VirtualMachineRestoreStatus, expectedComplete bool, expectedErr string) {
+		complete, err := execute.IsRestoreComplete(&snapshotv1alpha1.VirtualMachineRestore{
+			ObjectMeta: v1.ObjectMeta{Name: "vm-restore-abcde"},
+			Status:     status,
+		})
+		Expect(complete).To(Equal(expectedComplete))
+		if expectedErr == "" {
+			Expect(err).Should(Succeed())
+		} else {
+			Expect(err).To(MatchError(expectedErr))
+		}
+	},
+		Entry("no status", nil, false, ""),
+		Entry("in progress", &snapshotv1alpha1.VirtualMachineRestoreStatus{
+			Complete: boolPtr(false),
+			Conditions: []snapshotv1alpha1.Condition{
+				{Type: snapshotv1alpha1.ConditionProgressing, Status: corev1.ConditionTrue},
+				{Type: snapshotv1alpha1.ConditionFailure, Status: corev1.ConditionFalse},
+			},
+		}, false, ""),
+		Entry("complete", &snapshotv1alpha1.VirtualMachineRestoreStatus{
+			Complete: boolPtr(true),
+			Conditions: []snapshotv1alpha1.Condition{
+				{Type: snapshotv1alpha1.ConditionReady, Status: corev1.ConditionTrue},
+			},
+		}, true, ""),
+		Entry("failed", &snapshotv1alpha1.VirtualMachineRestoreStatus{
+			Complete: boolPtr(false),
+			Conditions: []snapshotv1alpha1.Condition{
+				{Type: snapshotv1alpha1.ConditionFailure, Status: corev1.ConditionTrue, Reason: "VirtualMachine is not stopped"},
+			},
+		}, false, "restore vm-restore-abcde failed: VirtualMachine is not stopped"),
+		Entry("failed with message", &snapshotv1alpha1.VirtualMachineRestoreStatus{
+			Complete: boolPtr(false),
+			Conditions: []snapshotv1alpha1.Condition{
+				{Type: snapshotv1alpha1.ConditionFailure, Status: corev1.ConditionTrue, Reason: "Operation failed", Message: "volume snapshot class not found"},
+			},
+		}, false, "restore vm-restore-abcde failed: Operation failed: volume snapshot class not found"),
+	)
+})
@@ -2,69 +2,64 @@ package execute
 
 import (
 	"fmt"
-	cmd2 "github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/cmd"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execattributes"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/parse"
-	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/options"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	"github.com/pkg/sftp"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+	"io"
 	"net"
-	"os"
-	"os/exec"
-	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
-const (
-	knownHostsFilename = "known_hosts"
-	idRSAFilename      = "id_rsa"
-)
-
-const (
-	defaultFileMode = 0600
-	defaultDirMode  = 0700
-)
-
 type sshExecutor struct {
-	clioptions *parse.CLIOptions
-	ssh        execattributes.SSHAttributes
-	ipAddress  string
+	ssh          execattributes.SSHAttributes
+	dialer       dialer
+	ipAddress    string
+	clientConfig *ssh.ClientConfig
 }
 
-func newSSHExecutor(clioptions *parse.CLIOptions, execAttributes execattributes.ExecAttributes) *sshExecutor {
-	return &sshExecutor{clioptions: clioptions, ssh: execAttributes.GetSSHAttributes()}
+func newSSHExecutor(execAttributes execattributes.ExecAttributes, dialer dialer) *sshExecutor {
+	return &sshExecutor{ssh: execAttributes.GetSSHAttributes(), dialer: dialer}
 }
 
 func (e *sshExecutor) Init(ipAddress string) error {
 	e.ipAddress = ipAddress
 
-	log.Logger().Debug("preparing ssh files")
-	if err := os.MkdirAll(e.ssh.GetSSHDir(), defaultDirMode); err != nil {
+	log.Logger().Debug("preparing ssh client config")
+	authMethods, err := getSSHAuthMethods(e.ssh)
+	if err != nil {
 		return err
 	}
 
-	if privateKey := e.ssh.GetPrivateKey(); privateKey != "" {
-		if err := writeToUserFile(path.Join(e.ssh.GetSSHDir(), idRSAFilename), privateKey, false); err != nil {
-			return err
-		}
+	hostKeyCallback, err := getSSHHostKeyCallback(e.ssh)
+	if err != nil {
+		return err
 	}
 
-	if hostPublicKey := e.ssh.GetHostPublicKey(); hostPublicKey != "" {
-		knownHost := fmt.Sprintf("%v %v", ipAddress, hostPublicKey)
-		if err := writeToUserFile(path.Join(e.ssh.GetSSHDir(), knownHostsFilename), knownHost, true); err != nil {
-			return err
-		}
+	e.clientConfig = &ssh.ClientConfig{
+		User:            e.ssh.GetUser(),
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         e.ssh.GetConnectTimeout(),
 	}
 
 	return nil
+}
 
+func (e *sshExecutor) RequiresIPAddress() bool {
+	return true
 }
 
 func (e *sshExecutor) TestConnection() bool {
-	address := net.JoinHostPort(e.ipAddress, strconv.Itoa(e.ssh.GetPort()))
-	conn, err := net.DialTimeout("tcp", address, constants.CheckSSHConnectionTimeout)
+	address := e.getAddress()
+	conn, err := e.dialer.Dial(address, constants.CheckConnectionTimeout)
 	if conn != nil {
 		defer conn.Close()
 	} else {
@@ -74,42 +69,144 @@ func (e *sshExecutor) TestConnection() bool {
 	return conn != nil && err == nil
 }
 
-func (e *sshExecutor) RemoteExecute(timeout time.Duration) error {
-	opts := options.NewCommandOptionsFromArray(e.ssh.GetAdditionalSSHOptions())
+func (e *sshExecutor) TestAuthentication() error {
+	_, closeClient, err := e.dial()
+	if err != nil {
+		return err
+	}
+	closeClient()
+	return nil
+}
 
-	destination := e.ssh.GetUser() + "@" + e.ipAddress
-	opts.AddValue(destination)
+func (e *sshExecutor) RemoteExecute(script string, timeout time.Duration, stdout, stderr io.Writer) error {
+	client, closeClient, err := e.dial()
+	if err != nil {
+		return err
+	}
+	defer closeClient()
 
-	log.Logger().Debug("executing ssh command with options: " + strings.Join(opts.GetAll(), " "))
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	session.Stdout = stdout
+	session.Stderr = stderr
 
 	// do not log script
-	opts.AddValue("--")
-	opts.AddValue(e.clioptions.GetScript())
+	return runSSHSessionWithTimeout(timeout, session, script)
+}
 
-	cmd := exec.Command(e.ssh.GetSSHExecutableName(), opts.GetAll()...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+func (e *sshExecutor) UploadFiles(files []parse.FileTransfer, timeout time.Duration) ([]string, error) {
+	return e.transferFiles(files, timeout, uploadSFTPFile)
+}
 
-	return cmd2.RunCmdWithTimeout(timeout, cmd)
+func (e *sshExecutor) DownloadFiles(files []parse.FileTransfer, timeout time.Duration) ([]string, error) {
+	return e.transferFiles(files, timeout, downloadSFTPFile)
 }
 
-func writeToUserFile(filename string, content string, append bool) error {
-	flags := os.O_CREATE | os.O_WRONLY
+func (e *sshExecutor) transferFiles(files []parse.FileTransfer, timeout time.Duration, transfer sftpTransferFunc) ([]string, error) {
+	client, closeClient, err := e.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer closeClient()
 
-	if append {
-		flags |= os.O_APPEND
-	} else {
-		flags |= os.O_TRUNC
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		return nil, err
+	}
+	defer sftpClient.Close()
+
+	timedOut := make(chan struct{})
+	if timeout > 0 {
+		timer := time.AfterFunc(timeout, func() {
+			close(timedOut)
+			_ = client.Close()
+		})
+		defer timer.Stop()
 	}
 
-	f, err := os.OpenFile(filename, flags, defaultFileMode)
+	var checksums []string
+	for _, file := range files {
+		log.Logger().Debug("transferring file", zap.String("source", file.Source), zap.String("destination", file.Destination))
+		checksum, err := transfer(sftpClient, file)
+		if err != nil {
+			select {
+			case <-timedOut:
+				return checksums, exit.Exit{
+					Code: constants.CommandTimeout,
+					Msg:  "file transfer timed out",
+					Soft: true,
+				}
+			default:
+				return checksums, fmt.Errorf("could not transfer %v to %v: %v", file.Source, file.Destination, err.Error())
+			}
+		}
+		checksums = append(checksums, checksum)
+	}
+
+	return checksums, nil
+}
+
+// dial connects to the VM and returns a func which closes the connection
+func (e *sshExecutor) dial() (*ssh.Client, func(), error) {
+	if e.clientConfig == nil {
+		return nil, nil, fmt.Errorf("ssh executor was not initialized")
+	}
+
+	address := e.getAddress()
+	log.Logger().Debug("connecting to ssh server", zap.String("user", e.ssh.GetUser()), zap.String("address", address))
+
+	conn, err := e.dialer.Dial(address, e.clientConfig.Timeout)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	defer f.Close()
 
-	if _, err := f.Write([]byte(content)); err != nil {
-		return err
+	readErrConn := &readErrorRecordingConn{Conn: conn}
+	clientConn, chans, reqs, err := ssh.NewClientConn(readErrConn, address, e.clientConfig)
+	if err != nil {
+		// the handshake errors do not wrap their cause, so the failed reads tell a lost connection from e.g. a failed authentication
+		if readErr := readErrConn.getReadErr(); readErr != nil && strings.HasSuffix(err.Error(), readErr.Error()) {
+			err = newConnectionError(err)
+		}
+		_ = conn.Close()
+		return nil, nil, err
 	}
-	return f.Sync()
+	client := ssh.NewClient(clientConn, chans, reqs)
+
+	stopKeepAlive := startSSHKeepAlive(client, e.ssh.GetServerAliveInterval(), e.ssh.GetServerAliveCountMax())
+
+	return client, func() {
+		close(stopKeepAlive)
+		_ = client.Close()
+	}, nil
+}
+
+func (e *sshExecutor) getAddress() string {
+	return net.JoinHostPort(e.ipAddress, strconv.Itoa(e.ssh.GetPort()))
+}
+
+// readErrorRecordingConn records the last read error of the connection
+type readErrorRecordingConn struct {
+	net.Conn
+	lock    sync.Mutex
+	readErr error
+}
+
+func (c *readErrorRecordingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if err != nil {
+		c.lock.Lock()
+		c.readErr = err
+		c.lock.Unlock()
+	}
+	return n, err
+}
+
+func (c *readErrorRecordingConn) getReadErr() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.readErr
 }
@@ -0,0 +1,220 @@
+package execute
+
+import (
+	"fmt"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execattributes"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zconstants/connectionsecret"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	keepAliveRequestName = "keepalive@openssh.com"
+)
+
+const (
+	strictHostKeyCheckingNo        = "no"
+	strictHostKeyCheckingAcceptNew = "accept-new"
+)
+
+func getSSHAuthMethods(sshAttributes execattributes.SSHAttributes) ([]ssh.AuthMethod, error) {
+	var authMethods []ssh.AuthMethod
+
+	if privateKey := sshAttributes.GetPrivateKey(); strings.TrimSpace(privateKey) != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %v: %v", connectionsecret.SSHConnectionSecretKeys.PrivateKey, err.Error())
+		}
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
+
+	if password := sshAttributes.GetPassword(); password != "" {
+		authMethods = append(authMethods,
+			ssh.Password(password),
+			ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				// answer all prompts (usually just "Password:") with the password
+				answers := make([]string, len(questions))
+				for idx := range questions {
+					answers[idx] = password
+				}
+				return answers, nil
+			}),
+		)
+	}
+
+	return authMethods, nil
+}
+
+func getSSHHostKeyCallback(sshAttributes execattributes.SSHAttributes) (ssh.HostKeyCallback, error) {
+	var hostPublicKey ssh.PublicKey
+
+	if hostPublicKeyStr := sshAttributes.GetHostPublicKey(); strings.TrimSpace(hostPublicKeyStr) != "" {
+		var err error
+		hostPublicKey, _, _, _, err = ssh.ParseAuthorizedKey([]byte(hostPublicKeyStr))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %v: %v", connectionsecret.SSHConnectionSecretKeys.HostPublicKey, err.Error())
+		}
+	}
+
+	switch sshAttributes.GetStrictHostKeyCheckingMode() {
+	case strictHostKeyCheckingNo:
+		return ssh.InsecureIgnoreHostKey(), nil
+	case strictHostKeyCheckingAcceptNew:
+		if hostPublicKey != nil {
+			return ssh.FixedHostKey(hostPublicKey), nil
+		}
+		return newAcceptNewHostKeyCallback(), nil
+	default:
+		if hostPublicKey == nil {
+			return nil, fmt.Errorf("%v is required for strict host key checking", connectionsecret.SSHConnectionSecretKeys.HostPublicKey)
+		}
+		return ssh.FixedHostKey(hostPublicKey), nil
+	}
+}
+
+// newAcceptNewHostKeyCallback accepts the first seen host key and rejects any other key in subsequent connections
+func newAcceptNewHostKeyCallback() ssh.HostKeyCallback {
+	var lock sync.Mutex
+	var knownHostKey ssh.PublicKey
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if knownHostKey == nil {
+			log.Logger().Debug("accepting new host key", zap.String("hostname", hostname), zap.String("fingerprint", ssh.FingerprintSHA256(key)))
+			knownHostKey = key
+			return nil
+		}
+
+		return ssh.FixedHostKey(knownHostKey)(hostname, remote, key)
+	}
+}
+
+// startSSHKeepAlive sends keepalive requests each interval and closes the client once countMax requests are left unanswered.
+// Like in OpenSSH, the client is never closed when countMax is 0.
+func startSSHKeepAlive(client *ssh.Client, interval time.Duration, countMax int) chan struct{} {
+	stop := make(chan struct{})
+
+	if interval <= 0 {
+		return stop
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		missed := 0
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			reply := make(chan error, 1)
+			go func() {
+				// the server replies with a failure for unknown requests which is also a valid sign of life
+				_, _, err := client.SendRequest(keepAliveRequestName, true, nil)
+				reply <- err
+			}()
+
+			select {
+			case <-stop:
+				return
+			case err := <-reply:
+				if err == nil {
+					missed = 0
+				} else {
+					missed++
+				}
+			case <-time.After(interval):
+				missed++
+			}
+
+			if countMax > 0 && missed >= countMax {
+				log.Logger().Debug("ssh server is not responding, closing the connection", zap.Int("missedKeepAlives", missed))
+				_ = client.Close()
+				return
+			}
+		}
+	}()
+
+	return stop
+}
+
+func runSSHSessionWithTimeout(timeout time.Duration, session *ssh.Session, command string) error {
+	if err := session.Start(command); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- session.Wait() }()
+
+	// forward termination of the task to the remote command
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var timeoutChan <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutChan = timer.C
+	}
+
+	for {
+		select {
+		case sig := <-signals:
+			log.Logger().Debug("forwarding signal to the remote command", zap.String("signal", sig.String()))
+			if err := session.Signal(toSSHSignal(sig)); err != nil {
+				log.Logger().Debug("could not forward signal", zap.Error(err))
+			}
+		case <-timeoutChan:
+			_ = session.Signal(ssh.SIGKILL)
+			return exit.Exit{
+				Code: constants.CommandTimeout,
+				Msg:  "command timed out",
+				Soft: true,
+			}
+		case err := <-done:
+			if err != nil {
+				if exitErr, ok := err.(*ssh.ExitError); ok {
+					if exitErr.Signal() != "" {
+						log.Logger().Debug("remote command was terminated by a signal", zap.String("signal", exitErr.Signal()))
+					}
+					// exit status of commands terminated by a signal is already converted to 128 + signal number
+					return exit.Exit{
+						Code: exitErr.ExitStatus(),
+						Soft: true,
+					}
+				}
+				return err
+			}
+
+			return exit.Exit{
+				Code: 0,
+				Soft: true,
+			}
+		}
+	}
+}
+
+func toSSHSignal(sig os.Signal) ssh.Signal {
+	switch sig {
+	case syscall.SIGINT:
+		return ssh.SIGINT
+	default:
+		return ssh.SIGTERM
+	}
+}
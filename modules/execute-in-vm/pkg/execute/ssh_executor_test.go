@@ -0,0 +1,336 @@
+package execute_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execattributes"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execute"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/parse"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utilstest"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	. "github.com/kubevirt/kubevirt-tekton-tasks/modules/sharedtest/testconstants"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const localhost = "127.0.0.1"
+
+// handles "exit CODE", "sleep DURATION" and "echo TEXT" commands
+func testCommandHandler(command string, stdout, stderr io.Writer, signals <-chan string) uint32 {
+	fields := strings.SplitN(command, " ", 2)
+	switch fields[0] {
+	case "exit":
+		code, _ := strconv.Atoi(fields[1])
+		return uint32(code)
+	case "sleep":
+		duration, _ := time.ParseDuration(fields[1])
+		select {
+		case <-time.After(duration):
+			return 0
+		case signal := <-signals:
+			_, _ = fmt.Fprint(stderr, signal)
+			return 137
+		}
+	case "echo":
+		_, _ = fmt.Fprint(stdout, fields[1])
+		return 0
+	default:
+		return 127
+	}
+}
+
+var _ = Describe("SSHExecutor", func() {
+	var testSecretPath string
+	var server *utilstest.SSHServer
+	var port int
+
+	BeforeEach(func() {
+		testSecretPath = path.Join(testPath, TestRandomName("ssh-executor-secret"))
+		Expect(os.MkdirAll(testSecretPath, testDirMode)).Should(Succeed())
+
+		server = utilstest.NewSSHServer(utilstest.SSHServerOptions{
+			User:           "fedora",
+			HostPrivateKey: SSHTestPrivateKey2,
+			AuthorizedKey:  SSHTestPublicKey,
+			Handler:        testCommandHandler,
+		})
+		port = server.Start()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(testSecretPath)).Should(Succeed())
+	})
+
+	newExecutor := func(secretSetup map[string]string) execute.RemoteExecutor {
+		PrepareTestSecret(testSecretPath, secretSetup)
+		attributes := execattributes.NewExecAttributes()
+		Expect(attributes.Init(testSecretPath)).Should(Succeed())
+
+		executor := execute.NewSSHExecutor(attributes, execute.NewNetDialer())
+		Expect(executor.Init(localhost)).Should(Succeed())
+		return executor
+	}
+
+	defaultSecret := func() map[string]string {
+		return map[string]string{
+			"type":                   "ssh",
+			"user":                   "fedora",
+			"ssh-privatekey":         SSHTestPrivateKey,
+			"host-public-key":        SSHTestPublicKey2,
+			"additional-ssh-options": fmt.Sprintf("-p %v", port),
+		}
+	}
+
+	DescribeTable("executes remote commands", func(script string, timeout time.Duration, expectedExitCode int) {
+		executor := newExecutor(defaultSecret())
+		Expect(executor.TestConnection()).To(BeTrue())
+
+		err := executor.RemoteExecute(script, timeout, GinkgoWriter, GinkgoWriter)
+		Expect(err).To(Equal(exit.Exit{Code: expectedExitCode, Soft: true}))
+		Expect(server.GetCommands()).To(Equal([]string{script}))
+	},
+		Entry("successful command", "echo hello", 0*time.Second, 0),
+		Entry("failing command", "exit 3", 0*time.Second, 3),
+		Entry("unknown command", "ls", 0*time.Second, 127),
+		Entry("command finishes before timeout", "sleep 10ms", 3*time.Second, 0),
+	)
+
+	It("writes the command output", func() {
+		var stdout, stderr bytes.Buffer
+		executor := newExecutor(defaultSecret())
+
+		Expect(executor.RemoteExecute("echo hello", 0, &stdout, &stderr)).To(Equal(exit.Exit{Code: 0, Soft: true}))
+		Expect(stdout.String()).To(Equal("hello"))
+		Expect(stderr.String()).To(BeEmpty())
+	})
+
+	It("kills the remote command on timeout", func() {
+		timeout := 200 * time.Millisecond
+		executor := newExecutor(defaultSecret())
+
+		start := time.Now()
+		err := executor.RemoteExecute("sleep 10s", timeout, GinkgoWriter, GinkgoWriter)
+		Expect(err).To(Equal(exit.Exit{Code: constants.CommandTimeout, Msg: "command timed out", Soft: true}))
+		Expect(time.Since(start)).Should(BeNumerically(">=", timeout))
+		Expect(time.Since(start)).Should(BeNumerically("<", 10*time.Second))
+	})
+
+	DescribeTable("keeps the connection alive", func(serverAliveCountMax string) {
+		secret := defaultSecret()
+		secret["additional-ssh-options"] += " -o ServerAliveInterval=1 -o ServerAliveCountMax=" + serverAliveCountMax
+		executor := newExecutor(secret)
+
+		Expect(executor.RemoteExecute("sleep 2500ms", 0, GinkgoWriter, GinkgoWriter)).To(Equal(exit.Exit{Code: 0, Soft: true}))
+	},
+		Entry("with a single missed keepalive allowed", "1"),
+		Entry("without closing unresponsive connections", "0"),
+	)
+
+	It("accepts any host key when strict host key checking is disabled", func() {
+		secret := defaultSecret()
+		delete(secret, "host-public-key")
+		secret["disable-strict-host-key-checking"] = "true"
+		executor := newExecutor(secret)
+
+		Expect(executor.RemoteExecute("exit 0", 0, GinkgoWriter, GinkgoWriter)).To(Equal(exit.Exit{Code: 0, Soft: true}))
+	})
+
+	It("accepts a new host key only once", func() {
+		secret := defaultSecret()
+		delete(secret, "host-public-key")
+		secret["disable-strict-host-key-checking"] = "true"
+		secret["additional-ssh-options"] += " -o StrictHostKeyChecking=accept-new"
+		executor := newExecutor(secret)
+
+		Expect(executor.RemoteExecute("exit 0", 0, GinkgoWriter, GinkgoWriter)).To(Equal(exit.Exit{Code: 0, Soft: true}))
+		Expect(executor.RemoteExecute("exit 0", 0, GinkgoWriter, GinkgoWriter)).To(Equal(exit.Exit{Code: 0, Soft: true}))
+	})
+
+	It("fails on host key mismatch", func() {
+		secret := defaultSecret()
+		secret["host-public-key"] = SSHTestPublicKey
+		executor := newExecutor(secret)
+
+		err := executor.RemoteExecute("exit 0", 0, GinkgoWriter, GinkgoWriter)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("host key mismatch"))
+		Expect(execute.IsConnectionError(err)).To(BeFalse())
+		Expect(server.GetCommands()).To(BeEmpty())
+	})
+
+	It("fails with unauthorized key", func() {
+		secret := defaultSecret()
+		secret["ssh-privatekey"] = SSHTestPrivateKey2
+		executor := newExecutor(secret)
+
+		err := executor.RemoteExecute("exit 0", 0, GinkgoWriter, GinkgoWriter)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unable to authenticate"))
+		Expect(execute.IsConnectionError(err)).To(BeFalse())
+		Expect(server.GetCommands()).To(BeEmpty())
+	})
+
+	It("fails with connection error when the connection is closed during the handshake", func() {
+		listener, err := net.Listen("tcp", net.JoinHostPort(localhost, "0"))
+		Expect(err).Should(Succeed())
+		defer listener.Close()
+		go func() {
+			if conn, err := listener.Accept(); err == nil {
+				_ = conn.Close()
+			}
+		}()
+
+		secret := defaultSecret()
+		secret["additional-ssh-options"] = fmt.Sprintf("-p %v", listener.Addr().(*net.TCPAddr).Port)
+		executor := newExecutor(secret)
+
+		err = executor.RemoteExecute("exit 0", 0, GinkgoWriter, GinkgoWriter)
+		Expect(err).To(MatchError(ContainSubstring("handshake failed")))
+		Expect(execute.IsConnectionError(err)).To(BeTrue())
+	})
+
+	DescribeTable("authenticates with password", func(serverOptions utilstest.SSHServerOptions, password string, shouldSucceed bool) {
+		server.Close()
+		serverOptions.User = "Administrator"
+		serverOptions.HostPrivateKey = SSHTestPrivateKey2
+		serverOptions.Handler = testCommandHandler
+		server = utilstest.NewSSHServer(serverOptions)
+		port = server.Start()
+
+		secret := defaultSecret()
+		secret["user"] = "Administrator"
+		secret["password"] = password
+		delete(secret, "ssh-privatekey")
+		executor := newExecutor(secret)
+
+		err := executor.RemoteExecute("exit 0", 0, GinkgoWriter, GinkgoWriter)
+		if shouldSucceed {
+			Expect(err).To(Equal(exit.Exit{Code: 0, Soft: true}))
+		} else {
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unable to authenticate"))
+		}
+	},
+		Entry("password", utilstest.SSHServerOptions{Password: "secret"}, "secret", true),
+		Entry("wrong password", utilstest.SSHServerOptions{Password: "secret"}, "guess", false),
+		Entry("keyboard-interactive", utilstest.SSHServerOptions{KeyboardInteractivePassword: "secret"}, "secret", true),
+		Entry("wrong keyboard-interactive password", utilstest.SSHServerOptions{KeyboardInteractivePassword: "secret"}, "guess", false),
+	)
+
+	It("detects missing connection", func() {
+		server.Close()
+		executor := newExecutor(defaultSecret())
+
+		Expect(executor.TestConnection()).To(BeFalse())
+	})
+})
+
+var _ = Describe("SSHExecutor file transfers", func() {
+	var testSecretPath, testFilesPath string
+	var server *utilstest.SSHServer
+	var executor execute.FileTransferer
+
+	BeforeEach(func() {
+		testSecretPath = path.Join(testPath, TestRandomName("ssh-transfer-secret"))
+		Expect(os.MkdirAll(testSecretPath, testDirMode)).Should(Succeed())
+
+		var err error
+		testFilesPath, err = filepath.Abs(path.Join(testPath, TestRandomName("ssh-transfer-files")))
+		Expect(err).Should(Succeed())
+		Expect(os.MkdirAll(testFilesPath, testDirMode)).Should(Succeed())
+
+		server = utilstest.NewSSHServer(utilstest.SSHServerOptions{
+			User:           "fedora",
+			HostPrivateKey: SSHTestPrivateKey2,
+			AuthorizedKey:  SSHTestPublicKey,
+			Handler:        testCommandHandler,
+		})
+		port := server.Start()
+
+		PrepareTestSecret(testSecretPath, map[string]string{
+			"type":                   "ssh",
+			"user":                   "fedora",
+			"ssh-privatekey":         SSHTestPrivateKey,
+			"host-public-key":        SSHTestPublicKey2,
+			"additional-ssh-options": fmt.Sprintf("-p %v", port),
+		})
+		attributes := execattributes.NewExecAttributes()
+		Expect(attributes.Init(testSecretPath)).Should(Succeed())
+
+		sshExecutor := execute.NewSSHExecutor(attributes, execute.NewNetDialer())
+		Expect(sshExecutor.Init(localhost)).Should(Succeed())
+		executor = sshExecutor
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(testSecretPath)).Should(Succeed())
+		Expect(os.RemoveAll(testFilesPath)).Should(Succeed())
+	})
+
+	sha256Sum := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	It("uploads files", func() {
+		Expect(os.WriteFile(path.Join(testFilesPath, "artifact.txt"), []byte("artifact"), 0600)).Should(Succeed())
+		Expect(os.WriteFile(path.Join(testFilesPath, "run.sh"), []byte("#!/bin/sh"), 0755)).Should(Succeed())
+
+		checksums, err := executor.UploadFiles([]parse.FileTransfer{
+			{Source: path.Join(testFilesPath, "artifact.txt"), Destination: path.Join(testFilesPath, "guest", "nested", "artifact.txt")},
+			{Source: path.Join(testFilesPath, "run.sh"), Destination: path.Join(testFilesPath, "guest", "run.sh")},
+		}, 0)
+		Expect(err).Should(Succeed())
+		Expect(checksums).To(Equal([]string{sha256Sum("artifact"), sha256Sum("#!/bin/sh")}))
+
+		content, err := os.ReadFile(path.Join(testFilesPath, "guest", "nested", "artifact.txt"))
+		Expect(err).Should(Succeed())
+		Expect(string(content)).To(Equal("artifact"))
+
+		info, err := os.Stat(path.Join(testFilesPath, "guest", "run.sh"))
+		Expect(err).Should(Succeed())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+	})
+
+	It("downloads files", func() {
+		Expect(os.MkdirAll(path.Join(testFilesPath, "guest"), testDirMode)).Should(Succeed())
+		Expect(os.WriteFile(path.Join(testFilesPath, "guest", "report.xml"), []byte("<report/>"), 0600)).Should(Succeed())
+
+		checksums, err := executor.DownloadFiles([]parse.FileTransfer{
+			{Source: path.Join(testFilesPath, "guest", "report.xml"), Destination: path.Join(testFilesPath, "reports", "report.xml")},
+		}, 3*time.Second)
+		Expect(err).Should(Succeed())
+		Expect(checksums).To(Equal([]string{sha256Sum("<report/>")}))
+
+		content, err := os.ReadFile(path.Join(testFilesPath, "reports", "report.xml"))
+		Expect(err).Should(Succeed())
+		Expect(string(content)).To(Equal("<report/>"))
+	})
+
+	It("returns checksums of files transferred before a failure", func() {
+		Expect(os.MkdirAll(path.Join(testFilesPath, "guest"), testDirMode)).Should(Succeed())
+		Expect(os.WriteFile(path.Join(testFilesPath, "guest", "first.log"), []byte("first"), 0600)).Should(Succeed())
+
+		checksums, err := executor.DownloadFiles([]parse.FileTransfer{
+			{Source: path.Join(testFilesPath, "guest", "first.log"), Destination: path.Join(testFilesPath, "first.log")},
+			{Source: path.Join(testFilesPath, "guest", "missing.log"), Destination: path.Join(testFilesPath, "missing.log")},
+		}, 0)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("could not transfer " + path.Join(testFilesPath, "guest", "missing.log")))
+		Expect(checksums).To(Equal([]string{sha256Sum("first")}))
+	})
+})
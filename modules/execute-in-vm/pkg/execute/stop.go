@@ -0,0 +1,178 @@
+package execute
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/report"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// EnsureVMStopped shuts down the guest gracefully and waits for the grace period. If the VM is still running
+// after the grace period, it is forced to stop. Fails if the VM does not stop within the stop timeout.
+func (e *Executor) EnsureVMStopped() error {
+	vmName := e.clioptions.VirtualMachineName
+	vmNamespace := e.clioptions.GetVirtualMachineNamespace()
+	logFields := []zap.Field{zap.String("name", vmName), zap.String("namespace", vmNamespace)}
+	gracePeriod := e.clioptions.GetStopGracePeriod()
+	timeout := e.clioptions.GetStopTimeout()
+	start := time.Now()
+
+	if stopped, err := e.isVMStopped(); stopped || err != nil {
+		return err
+	}
+
+	if gracePeriod > 0 {
+		if err := e.ensureVMStop(); err != nil {
+			return err
+		}
+
+		err := e.waitForVMStopped(gracePeriod)
+		if err != wait.ErrWaitTimeout {
+			return err
+		}
+		log.Logger().Warn("vm did not shut down within the grace period, forcing it to stop", append(logFields, zap.Duration("gracePeriod", gracePeriod))...)
+	}
+
+	if err := e.forceVMStop(); err != nil {
+		return err
+	}
+
+	if err := e.waitForVMStopped(timeout - time.Since(start)); err != nil {
+		if err == wait.ErrWaitTimeout {
+			return fmt.Errorf("vm %v did not stop within %v", vmName, timeout)
+		}
+		return err
+	}
+	return nil
+}
+
+func (e *Executor) waitForVMStopped(timeout time.Duration) error {
+	if timeout <= 0 {
+		return wait.ErrWaitTimeout
+	}
+
+	return wait.PollImmediate(constants.PollVMItoStopInterval, timeout, func() (bool, error) {
+		stopped, err := e.isVMStopped()
+		if !stopped && err == nil {
+			log.Logger().Debug("waiting for a VM to stop", zap.String("name", e.clioptions.VirtualMachineName), zap.String("namespace", e.clioptions.GetVirtualMachineNamespace()))
+		}
+		return stopped, err
+	})
+}
+
+func (e *Executor) isVMStopped() (bool, error) {
+	vmi, err := e.kubevirtClient.VirtualMachineInstance(e.clioptions.GetVirtualMachineNamespace()).Get(e.clioptions.VirtualMachineName, &v1.GetOptions{})
+
+	if err == nil {
+		switch vmi.Status.Phase {
+		case kubevirtv1.Succeeded, kubevirtv1.Failed:
+			return true, nil
+		}
+		return false, nil
+	}
+
+	switch t := err.(type) {
+	case *errors.StatusError:
+		if t.Status().Reason == v1.StatusReasonNotFound {
+			return true, nil
+		}
+		return false, err
+	default:
+		return false, err
+	}
+}
+
+// ensureVMStop shuts down the guest gracefully. KubeVirt shuts down the guest through the guest agent or ACPI
+// when the VM is stopped. In the guest-command mode, the shutdown command is executed in the guest first.
+func (e *Executor) ensureVMStop() error {
+	vmName := e.clioptions.VirtualMachineName
+	vmNamespace := e.clioptions.GetVirtualMachineNamespace()
+	if !e.attemptedStop {
+		e.attemptedStop = true
+
+		if e.clioptions.GetStopMode() == constants.GuestCommandStopMode {
+			e.executeShutdownCommand()
+		}
+
+		// the VM has to be stopped even if the guest shut down, otherwise it could be started again by its run strategy
+		log.Logger().Debug("stopping a vm", zap.String("name", vmName), zap.String("namespace", vmNamespace))
+		if err := e.kubevirtClient.VirtualMachine(vmNamespace).Stop(vmName, &kubevirtv1.StopOptions{}); err != nil && !isConflict(err) {
+			return err
+		}
+		e.report.AddEvent(report.VMStopRequestedEvent)
+	}
+
+	return nil
+}
+
+// executeShutdownCommand executes the shutdown command in the guest. Failures are only logged
+// because the VM is stopped through the API as well.
+func (e *Executor) executeShutdownCommand() {
+	logFields := []zap.Field{zap.String("name", e.clioptions.VirtualMachineName), zap.String("namespace", e.clioptions.GetVirtualMachineNamespace())}
+
+	if e.executor == nil || !e.connected {
+		log.Logger().Warn("cannot execute the shutdown command, the vm is not connected", logFields...)
+		return
+	}
+
+	log.Logger().Debug("executing the shutdown command", append(logFields, zap.String("command", e.shutdownCommand))...)
+	err := e.executor.RemoteExecute(e.shutdownCommand, constants.ShutdownCommandTimeout, io.Discard, io.Discard)
+	switch {
+	case isSuccess(err):
+		log.Logger().Debug("the shutdown command finished", logFields...)
+	case isShutdownCommandFailure(err):
+		log.Logger().Warn("the shutdown command failed", append(logFields, zap.Error(err))...)
+	default:
+		// the connection is usually closed by the shutting down guest
+		log.Logger().Debug("the shutdown command did not finish", append(logFields, zap.Error(err))...)
+	}
+}
+
+// isShutdownCommandFailure returns true if the shutdown command exited with a non-zero exit code
+func isShutdownCommandFailure(err error) bool {
+	exitErr, ok := err.(exit.Exit)
+	return ok && exitErr.Code != 0
+}
+
+// forceVMStop stops the VM without waiting for the guest to shut down
+func (e *Executor) forceVMStop() error {
+	vmName := e.clioptions.VirtualMachineName
+	vmNamespace := e.clioptions.GetVirtualMachineNamespace()
+	gracePeriod := int64(0)
+
+	log.Logger().Debug("forcing a vm to stop", zap.String("name", vmName), zap.String("namespace", vmNamespace))
+	if err := e.kubevirtClient.VirtualMachine(vmNamespace).ForceStop(vmName, &kubevirtv1.StopOptions{GracePeriod: &gracePeriod}); err != nil && !isConflict(err) {
+		return err
+	}
+	e.report.AddEvent(report.VMForceStopRequestedEvent)
+
+	return nil
+}
+
+func isConflict(err error) bool {
+	if t, ok := err.(*errors.StatusError); ok {
+		// stop already requested
+		return t.Status().Reason == v1.StatusReasonConflict
+	}
+	return false
+}
+
+func getDefaultShutdownCommand(secretType constants.ExecSecretType) string {
+	switch secretType {
+	case constants.WinRMSecretType:
+		return constants.DefaultWinRMShutdownCommand
+	case constants.GuestAgentSecretType:
+		return constants.DefaultGuestAgentShutdownCommand
+	default:
+		return constants.DefaultSSHShutdownCommand
+	}
+}
@@ -0,0 +1,41 @@
+package execute_test
+
+import (
+	"errors"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execute"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ = Describe("Stop", func() {
+	DescribeTable("returns default shutdown command", func(secretType constants.ExecSecretType, expectedCommand string) {
+		Expect(execute.GetDefaultShutdownCommand(secretType)).To(Equal(expectedCommand))
+	},
+		Entry("ssh", constants.SSHSecretType, "sudo shutdown -h now"),
+		Entry("winrm", constants.WinRMSecretType, "shutdown /s /t 0"),
+		Entry("guest agent", constants.GuestAgentSecretType, "shutdown -h now"),
+	)
+
+	DescribeTable("detects already requested stop", func(err error, expectedConflict bool) {
+		Expect(execute.IsConflict(err)).To(Equal(expectedConflict))
+	},
+		Entry("conflict", k8serrors.NewConflict(schema.GroupResource{Resource: "virtualmachines"}, "vm", errors.New("VM is not running")), true),
+		Entry("not found", k8serrors.NewNotFound(schema.GroupResource{Resource: "virtualmachines"}, "vm"), false),
+		Entry("other error", errors.New("connection refused"), false),
+	)
+
+	DescribeTable("detects failed shutdown command", func(err error, expectedFailure bool) {
+		Expect(execute.IsShutdownCommandFailure(err)).To(Equal(expectedFailure))
+	},
+		Entry("no error", nil, false),
+		Entry("zero exit code", exit.Exit{Code: 0, Soft: true}, false),
+		Entry("non-zero exit code", exit.Exit{Code: 1, Soft: true}, true),
+		Entry("timeout", exit.Exit{Code: constants.CommandTimeout, Msg: "command timed out", Soft: true}, true),
+		Entry("closed connection", errors.New("connection reset by peer"), false),
+	)
+})
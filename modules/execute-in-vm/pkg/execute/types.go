@@ -1,9 +1,31 @@
 package execute
 
-import "time"
+import (
+	"io"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/parse"
+)
 
 type RemoteExecutor interface {
+	// Init prepares the executor to connect to ipAddress; the VMI name is used instead when the connection is port forwarded
 	Init(ipAddress string) error
+	// RequiresIPAddress reports whether the executor connects to the VM over the pod network
+	RequiresIPAddress() bool
 	TestConnection() bool
-	RemoteExecute(timeout time.Duration) error
+	// RemoteExecute runs the script and writes its output to stdout and stderr
+	RemoteExecute(script string, timeout time.Duration, stdout, stderr io.Writer) error
+}
+
+// Authenticator is implemented by remote executors which can check the credentials without executing a script
+type Authenticator interface {
+	TestAuthentication() error
+}
+
+// FileTransferer is implemented by remote executors which can copy files to and from the VM.
+// Both methods return sha256 checksums of the transferred files in the order of files;
+// only checksums of files transferred before an error are returned.
+type FileTransferer interface {
+	UploadFiles(files []parse.FileTransfer, timeout time.Duration) ([]string, error)
+	DownloadFiles(files []parse.FileTransfer, timeout time.Duration) ([]string, error)
 }
@@ -0,0 +1,172 @@
+package execute
+
+import (
+	"fmt"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execattributes"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/log"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	"github.com/masterzen/winrm"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type winRMExecutor struct {
+	winRM     execattributes.WinRMAttributes
+	dialer    dialer
+	ipAddress string
+	client    *winrm.Client
+}
+
+func newWinRMExecutor(execAttributes execattributes.ExecAttributes, dialer dialer) *winRMExecutor {
+	return &winRMExecutor{winRM: execAttributes.GetWinRMAttributes(), dialer: dialer}
+}
+
+func (e *winRMExecutor) Init(ipAddress string) error {
+	e.ipAddress = ipAddress
+
+	log.Logger().Debug("preparing winrm client")
+	var caCert []byte
+	if cert := e.winRM.GetCACert(); cert != "" {
+		caCert = []byte(cert)
+	}
+
+	host := ipAddress
+	if strings.Contains(host, ":") {
+		// the endpoint URL is not escaped so IPv6 addresses have to be enclosed in brackets
+		host = "[" + host + "]"
+	}
+
+	endpoint := winrm.NewEndpoint(host, e.winRM.GetPort(), e.winRM.UseHTTPS(), e.winRM.IsCertificateValidationDisabled(), caCert, nil, nil, constants.WinRMConnectTimeout)
+
+	parameters := *winrm.DefaultParameters
+	parameters.Dial = func(_, address string) (net.Conn, error) {
+		return e.dialer.Dial(address, constants.WinRMConnectTimeout)
+	}
+
+	client, err := winrm.NewClientWithParameters(endpoint, e.winRM.GetUser(), e.winRM.GetPassword(), &parameters)
+	if err != nil {
+		return err
+	}
+	e.client = client
+
+	return nil
+}
+
+func (e *winRMExecutor) RequiresIPAddress() bool {
+	return true
+}
+
+func (e *winRMExecutor) TestConnection() bool {
+	address := e.getAddress()
+	conn, err := e.dialer.Dial(address, constants.CheckConnectionTimeout)
+	if conn != nil {
+		defer conn.Close()
+	} else {
+		log.Logger().Debug("connection not found: " + address)
+	}
+
+	return conn != nil && err == nil
+}
+
+func (e *winRMExecutor) TestAuthentication() error {
+	if e.client == nil {
+		return fmt.Errorf("winrm executor was not initialized")
+	}
+
+	shell, err := e.client.CreateShell()
+	if err != nil {
+		return err
+	}
+	return shell.Close()
+}
+
+func (e *winRMExecutor) RemoteExecute(script string, timeout time.Duration, stdout, stderr io.Writer) error {
+	if e.client == nil {
+		return fmt.Errorf("winrm executor was not initialized")
+	}
+
+	log.Logger().Debug("executing winrm command", zap.String("user", e.winRM.GetUser()), zap.String("address", e.getAddress()))
+
+	shell, err := e.client.CreateShell()
+	if err != nil {
+		return err
+	}
+	defer shell.Close()
+
+	// do not log script
+	command, err := shell.Execute(winrm.Powershell(script))
+	if err != nil {
+		return err
+	}
+
+	stdoutOutput, stderrOutput := newDetachableOutput(stdout), newDetachableOutput(stderr)
+	var wg sync.WaitGroup
+	var stdoutErr, stderrErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, stdoutErr = io.Copy(stdoutOutput, command.Stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		_, stderrErr = io.Copy(stderrOutput, command.Stderr)
+	}()
+
+	fetched := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		command.Wait()
+		close(fetched)
+		wg.Wait()
+		close(done)
+	}()
+
+	var timeoutChan <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutChan = timer.C
+	}
+
+	select {
+	case <-timeoutChan:
+		_ = command.Close()
+		// the output streams are not closed together with the command, so the copying never finishes;
+		// the output fetched until the command is closed is kept and the rest is dropped
+		select {
+		case <-fetched:
+		case <-time.After(constants.WinRMCloseTimeout):
+		}
+		stdoutOutput.Detach()
+		stderrOutput.Detach()
+		return exit.Exit{
+			Code: constants.CommandTimeout,
+			Msg:  "command timed out",
+			Soft: true,
+		}
+	case <-done:
+	}
+	_ = command.Close()
+
+	// errors of the remote command are propagated through its output streams
+	for _, err := range []error{stdoutErr, stderrErr} {
+		if err != nil {
+			return err
+		}
+	}
+
+	return exit.Exit{
+		Code: command.ExitCode(),
+		Soft: true,
+	}
+}
+
+func (e *winRMExecutor) getAddress() string {
+	return net.JoinHostPort(e.ipAddress, strconv.Itoa(e.winRM.GetPort()))
+}
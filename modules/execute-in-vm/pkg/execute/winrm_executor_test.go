@@ -0,0 +1,164 @@
+package execute_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execattributes"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/execute"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utilstest"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
+	. "github.com/kubevirt/kubevirt-tekton-tasks/modules/sharedtest/testconstants"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// handles "exit CODE", "Start-Sleep -Milliseconds DURATION" and "Write-Output TEXT" scripts
+func testScriptHandler(script string, stdout, stderr io.Writer, terminated <-chan struct{}) int {
+	fields := strings.SplitN(script, " ", 2)
+	switch fields[0] {
+	case "exit":
+		code, _ := strconv.Atoi(fields[1])
+		return code
+	case "Start-Sleep":
+		duration, _ := strconv.Atoi(strings.TrimPrefix(fields[1], "-Milliseconds "))
+		select {
+		case <-time.After(time.Duration(duration) * time.Millisecond):
+			return 0
+		case <-terminated:
+			_, _ = fmt.Fprint(stderr, "terminated")
+			return 1
+		}
+	case "Write-Output":
+		_, _ = fmt.Fprint(stdout, fields[1])
+		return 0
+	default:
+		return 1
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+var _ = Describe("WinRMExecutor", func() {
+	var testSecretPath string
+	var server *utilstest.WinRMServer
+	var port int
+
+	BeforeEach(func() {
+		testSecretPath = path.Join(testPath, TestRandomName("winrm-executor-secret"))
+		Expect(os.MkdirAll(testSecretPath, testDirMode)).Should(Succeed())
+
+		server = utilstest.NewWinRMServer(utilstest.WinRMServerOptions{
+			User:     "Administrator",
+			Password: "secret",
+			Handler:  testScriptHandler,
+		})
+		port = server.Start()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(testSecretPath)).Should(Succeed())
+	})
+
+	newExecutor := func(secretSetup map[string]string) execute.RemoteExecutor {
+		PrepareTestSecret(testSecretPath, secretSetup)
+		attributes := execattributes.NewExecAttributes()
+		Expect(attributes.Init(testSecretPath)).Should(Succeed())
+
+		executor := execute.NewWinRMExecutor(attributes, execute.NewNetDialer())
+		Expect(executor.Init(localhost)).Should(Succeed())
+		return executor
+	}
+
+	defaultSecret := func() map[string]string {
+		return map[string]string{
+			"type":     "winrm",
+			"user":     "Administrator",
+			"password": "secret",
+			"port":     strconv.Itoa(port),
+		}
+	}
+
+	DescribeTable("executes remote scripts", func(script string, timeout time.Duration, expectedExitCode int) {
+		executor := newExecutor(defaultSecret())
+		Expect(executor.TestConnection()).To(BeTrue())
+
+		err := executor.RemoteExecute(script, timeout, GinkgoWriter, GinkgoWriter)
+		Expect(err).To(Equal(exit.Exit{Code: expectedExitCode, Soft: true}))
+		Expect(server.GetScripts()).To(Equal([]string{script}))
+	},
+		Entry("successful script", "Write-Output hello", 0*time.Second, 0),
+		Entry("failing script", "exit 3", 0*time.Second, 3),
+		Entry("unknown script", "Get-Foo", 0*time.Second, 1),
+		Entry("script finishes before timeout", "Start-Sleep -Milliseconds 10", 3*time.Second, 0),
+	)
+
+	It("writes the script output", func() {
+		var stdout, stderr bytes.Buffer
+		executor := newExecutor(defaultSecret())
+
+		Expect(executor.RemoteExecute("Write-Output hello", 0, &stdout, &stderr)).To(Equal(exit.Exit{Code: 0, Soft: true}))
+		Expect(stdout.String()).To(Equal("hello"))
+		Expect(stderr.String()).To(BeEmpty())
+	})
+
+	It("terminates the remote script on timeout", func() {
+		timeout := 200 * time.Millisecond
+		executor := newExecutor(defaultSecret())
+
+		start := time.Now()
+		err := executor.RemoteExecute("Start-Sleep -Milliseconds 10000", timeout, GinkgoWriter, GinkgoWriter)
+		Expect(err).To(Equal(exit.Exit{Code: constants.CommandTimeout, Msg: "command timed out", Soft: true}))
+		Expect(time.Since(start)).Should(BeNumerically(">=", timeout))
+		Expect(time.Since(start)).Should(BeNumerically("<", 10*time.Second))
+	})
+
+	It("does not write the output after the timeout", func() {
+		executor := newExecutor(defaultSecret())
+
+		var returned atomic.Bool
+		var lateWrites atomic.Int64
+		stderr := writerFunc(func(p []byte) (int, error) {
+			if returned.Load() {
+				lateWrites.Add(1)
+			}
+			return GinkgoWriter.Write(p)
+		})
+
+		err := executor.RemoteExecute("Start-Sleep -Milliseconds 10000", 200*time.Millisecond, GinkgoWriter, stderr)
+		returned.Store(true)
+		Expect(err).To(Equal(exit.Exit{Code: constants.CommandTimeout, Msg: "command timed out", Soft: true}))
+		Consistently(lateWrites.Load, 500*time.Millisecond).Should(BeZero())
+	})
+
+	It("fails with wrong password", func() {
+		secret := defaultSecret()
+		secret["password"] = "guess"
+		executor := newExecutor(secret)
+
+		err := executor.RemoteExecute("exit 0", 0, GinkgoWriter, GinkgoWriter)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("401"))
+		Expect(server.GetScripts()).To(BeEmpty())
+	})
+
+	It("detects missing connection", func() {
+		server.Close()
+		executor := newExecutor(defaultSecret())
+
+		Expect(executor.TestConnection()).To(BeFalse())
+	})
+})
@@ -0,0 +1,162 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Events recorded by the executor
+const (
+	VMStartRequestedEvent = "VMStartRequested"
+	VMRunningEvent        = "VMRunning"
+	IPAddressFoundEvent   = "IPAddressFound"
+	ConnectionReadyEvent  = "ConnectionReady"
+
+	VMStopRequestedEvent      = "VMStopRequested"
+	VMForceStopRequestedEvent = "VMForceStopRequested"
+)
+
+// Phase is an action executed in a VM, e.g. waiting for the VM to run or executing the script
+type Phase struct {
+	Name     string    `json:"name"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Duration string    `json:"duration"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Event is a point in time reached during a phase, e.g. the VM got an IP address
+type Event struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+}
+
+// VirtualMachineReport describes the execution in a single VM. Errors are keyed by the action which failed.
+type VirtualMachineReport struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Phases    []Phase           `json:"phases"`
+	Events    []Event           `json:"events,omitempty"`
+	ExitCode  *int              `json:"exitCode,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+
+	lock sync.Mutex
+}
+
+// Report describes the whole execution. ExitCode is the exit code of the task.
+type Report struct {
+	Start           time.Time               `json:"start"`
+	End             time.Time               `json:"end"`
+	Duration        string                  `json:"duration"`
+	ExitCode        int                     `json:"exitCode"`
+	Error           string                  `json:"error,omitempty"`
+	VirtualMachines []*VirtualMachineReport `json:"virtualMachines"`
+
+	lock sync.Mutex
+}
+
+func NewReport() *Report {
+	return &Report{Start: time.Now(), VirtualMachines: []*VirtualMachineReport{}}
+}
+
+// AddVirtualMachine adds a report of a VM in the order of the calls
+func (r *Report) AddVirtualMachine(name, namespace string) *VirtualMachineReport {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	vmReport := &VirtualMachineReport{Name: name, Namespace: namespace, Phases: []Phase{}}
+	r.VirtualMachines = append(r.VirtualMachines, vmReport)
+	return vmReport
+}
+
+// Finish records the end of the execution with the exit code and the error of the task
+func (r *Report) Finish(exitCode int, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.End = time.Now()
+	r.Duration = r.End.Sub(r.Start).String()
+	r.ExitCode = exitCode
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// Write writes the report as json to filePath
+func (r *Report) Write(filePath string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, vmReport := range r.VirtualMachines {
+		vmReport.lock.Lock()
+		defer vmReport.lock.Unlock()
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, append(data, '\n'), 0644)
+}
+
+// RunPhase runs fn and records its duration and error
+func (v *VirtualMachineReport) RunPhase(name string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	end := time.Now()
+
+	phase := Phase{Name: name, Start: start, End: end, Duration: end.Sub(start).String()}
+	if err != nil {
+		phase.Error = err.Error()
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.Phases = append(v.Phases, phase)
+
+	return err
+}
+
+// AddEvent records the first time the event happened. It is a no-op for nil reports.
+func (v *VirtualMachineReport) AddEvent(name string) {
+	if v == nil {
+		return
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	for _, event := range v.Events {
+		if event.Name == name {
+			return
+		}
+	}
+	v.Events = append(v.Events, Event{Name: name, Time: time.Now()})
+}
+
+// AddError records the error of the action
+func (v *VirtualMachineReport) AddError(name string, err error) {
+	if err == nil {
+		return
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if v.Errors == nil {
+		v.Errors = map[string]string{}
+	}
+	v.Errors[name] = err.Error()
+}
+
+// SetExitCode records the exit code of the script
+func (v *VirtualMachineReport) SetExitCode(exitCode int) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.ExitCode = &exitCode
+}
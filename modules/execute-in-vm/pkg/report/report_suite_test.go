@@ -0,0 +1,18 @@
+package report_test
+
+import (
+	"testing"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utilstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestReport(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Report Suite")
+}
+
+var _ = BeforeSuite(utilstest.SetupTestSuite)
+var _ = AfterSuite(utilstest.TearDownSuite)
@@ -0,0 +1,86 @@
+package report_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/report"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Report", func() {
+	It("records phases", func() {
+		vmReport := report.NewReport().AddVirtualMachine("vm", "default")
+
+		Expect(vmReport.RunPhase("EnsureVMRunning", func() error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})).Should(Succeed())
+		Expect(vmReport.RunPhase("SetupConnection", func() error {
+			return errors.New("connection refused")
+		})).To(MatchError("connection refused"))
+
+		Expect(vmReport.Phases).To(HaveLen(2))
+		Expect(vmReport.Phases[0].Name).To(Equal("EnsureVMRunning"))
+		Expect(vmReport.Phases[0].End.Sub(vmReport.Phases[0].Start)).Should(BeNumerically(">=", 10*time.Millisecond))
+		Expect(vmReport.Phases[0].Error).To(BeEmpty())
+		Expect(vmReport.Phases[1].Name).To(Equal("SetupConnection"))
+		Expect(vmReport.Phases[1].Error).To(Equal("connection refused"))
+	})
+
+	It("records only the first occurrence of events", func() {
+		vmReport := report.NewReport().AddVirtualMachine("vm", "default")
+
+		vmReport.AddEvent(report.VMRunningEvent)
+		vmReport.AddEvent(report.IPAddressFoundEvent)
+		vmReport.AddEvent(report.VMRunningEvent)
+
+		Expect(vmReport.Events).To(HaveLen(2))
+		Expect(vmReport.Events[0].Name).To(Equal(report.VMRunningEvent))
+		Expect(vmReport.Events[1].Name).To(Equal(report.IPAddressFoundEvent))
+	})
+
+	It("ignores events of nil reports", func() {
+		var vmReport *report.VirtualMachineReport
+		Expect(func() { vmReport.AddEvent(report.VMRunningEvent) }).ToNot(Panic())
+	})
+
+	It("writes json report", func() {
+		executionReport := report.NewReport()
+		vmReport := executionReport.AddVirtualMachine("vm", "default")
+		vmReport.AddEvent(report.VMStartRequestedEvent)
+		_ = vmReport.RunPhase("RemoteExecute", func() error { return nil })
+		vmReport.AddError("VM Stop", errors.New("vm could not be stopped"))
+		vmReport.AddError("VM Delete", nil)
+		vmReport.SetExitCode(1)
+		executionReport.Finish(-3, errors.New("vm could not be stopped"))
+
+		reportFile := filepath.Join(GinkgoT().TempDir(), "reports", "report.json")
+		Expect(executionReport.Write(reportFile)).Should(Succeed())
+
+		data, err := os.ReadFile(reportFile)
+		Expect(err).Should(Succeed())
+
+		var parsedReport map[string]interface{}
+		Expect(json.Unmarshal(data, &parsedReport)).Should(Succeed())
+		Expect(parsedReport).To(HaveKeyWithValue("exitCode", float64(-3)))
+		Expect(parsedReport).To(HaveKeyWithValue("error", "vm could not be stopped"))
+		Expect(parsedReport).To(HaveKey("start"))
+		Expect(parsedReport).To(HaveKey("end"))
+		Expect(parsedReport).To(HaveKey("duration"))
+
+		vmReports := parsedReport["virtualMachines"].([]interface{})
+		Expect(vmReports).To(HaveLen(1))
+		parsedVMReport := vmReports[0].(map[string]interface{})
+		Expect(parsedVMReport).To(HaveKeyWithValue("name", "vm"))
+		Expect(parsedVMReport).To(HaveKeyWithValue("namespace", "default"))
+		Expect(parsedVMReport).To(HaveKeyWithValue("exitCode", float64(1)))
+		Expect(parsedVMReport).To(HaveKeyWithValue("errors", map[string]interface{}{"VM Stop": "vm could not be stopped"}))
+		Expect(parsedVMReport["phases"]).To(HaveLen(1))
+		Expect(parsedVMReport["events"]).To(HaveLen(1))
+	})
+})
@@ -1,31 +1,104 @@
 package parse
 
 import (
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zutils"
 	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const (
-	vmNameOptionName      = "vm-name"
-	vmNamespaceOptionName = "vm-namespace"
-	stopOptionName        = "stop"
-	deleteOptionName      = "delete"
-	commandOptionName     = "command"
-	commandArgsOptionName = "command-args"
-	scriptOptionName      = "script"
+	vmNameOptionName                  = "vm-name"
+	vmNamesOptionName                 = "vm-names"
+	vmSelectorOptionName              = "vm-selector"
+	concurrencyOptionName             = "concurrency"
+	vmNamespaceOptionName             = "vm-namespace"
+	stopOptionName                    = "stop"
+	deleteOptionName                  = "delete"
+	stopModeOptionName                = "stop-mode"
+	shutdownCommandOptionName         = "shutdown-command"
+	stopGracePeriodOptionName         = "stop-grace-period"
+	stopTimeoutOptionName             = "stop-timeout"
+	commandOptionName                 = "command"
+	commandArgsOptionName             = "command-args"
+	scriptOptionName                  = "script"
+	playbookOptionName                = "playbook"
+	playbookFileOptionName            = "playbook-file"
+	uploadOptionName                  = "upload"
+	downloadOptionName                = "download"
+	stdoutFileOptionName              = "stdout-file"
+	stderrFileOptionName              = "stderr-file"
+	networkOptionName                 = "network"
+	ipFamilyOptionName                = "ip-family"
+	serviceOptionName                 = "service"
+	portForwardOptionName             = "port-forward"
+	readinessGatesOptionName          = "readiness-gates"
+	readinessProbeOptionName          = "readiness-probe"
+	restoreSnapshotOptionName         = "restore-snapshot"
+	maxAttemptsOptionName             = "max-attempts"
+	retryBackoffOptionName            = "retry-backoff"
+	retryOnExitCodesOptionName        = "retry-on-exit-codes"
+	retryOnConnectionErrorsOptionName = "retry-on-connection-errors"
 )
 
+// ReadinessGate describes a condition to wait for before the script is executed.
+// Zero Timeout means the gate is bounded only by the connection timeout.
+type ReadinessGate struct {
+	Type    constants.ReadinessGateType
+	Timeout time.Duration
+}
+
+// FileTransfer describes a file to copy from Source to Destination
+type FileTransfer struct {
+	Source      string
+	Destination string
+}
+
 type CLIOptions struct {
-	VirtualMachineName      string   `arg:"--vm-name,env:VM_NAME,required" placeholder:"NAME" help:"Name of a VM to execute the action in"`
+	VirtualMachineName      string   `arg:"--vm-name,env:VM_NAME" placeholder:"NAME" help:"Name of a VM to execute the action in"`
+	VirtualMachineNames     string   `arg:"--vm-names,env:VM_NAMES" placeholder:"NAME,..." help:"Newline or comma separated names of VMs to execute the action in"`
+	VirtualMachineSelector  string   `arg:"--vm-selector,env:VM_SELECTOR" placeholder:"SELECTOR" help:"Label selector of VMs to execute the action in"`
+	Concurrency             string   `arg:"--concurrency,env:CONCURRENCY" placeholder:"N" help:"Maximum number of VMs to execute the action in at the same time"`
 	VirtualMachineNamespace string   `arg:"--vm-namespace,env:VM_NAMESPACE" placeholder:"NAMESPACE" help:"Namespace of a VM to execute the action in"`
 	Stop                    string   `arg:"--stop" placeholder:"true|false" help:"Stops the VM after executing the action"`
 	Delete                  string   `arg:"--delete" placeholder:"true|false" help:"Deletes the VM after executing the action"`
+	StopMode                string   `arg:"--stop-mode,env:STOP_MODE" placeholder:"acpi|guest-command" help:"How to shut down the guest gracefully before the VM is forced to stop"`
+	ShutdownCommand         string   `arg:"--shutdown-command,env:SHUTDOWN_COMMAND" placeholder:"COMMAND" help:"Command to shut down the guest with in the guest-command stop mode. Defaults to a command suitable for the connection secret type"`
+	StopGracePeriod         string   `arg:"--stop-grace-period,env:STOP_GRACE_PERIOD" placeholder:"DURATION" help:"Time to wait for the guest to shut down before the VM is forced to stop. Should be in a 3h2m1s format."`
+	StopTimeout             string   `arg:"--stop-timeout,env:STOP_TIMEOUT" placeholder:"DURATION" help:"Time to wait for the VM to stop, including the grace period, before failing. Should be in a 3h2m1s format."`
 	Timeout                 string   `arg:"--timeout" help:"Timeout for the command/script (includes potential VM start). The VM will be stoped or deleted accordingly once the timout expires. Should be in a 3h2m1s format."`
 	Script                  string   `arg:"--script,env:EXECUTE_SCRIPT" placeholder:"SCRIPT" help:"Script to execute in a VM (can be set by EXECUTE_SCRIPT env variable)"`
+	Playbook                string   `arg:"--playbook,env:PLAYBOOK" placeholder:"PLAYBOOK" help:"Ansible playbook to run against the VM instead of the script"`
+	PlaybookFile            string   `arg:"--playbook-file,env:PLAYBOOK_FILE" placeholder:"FILE" help:"File with an Ansible playbook to run against the VM instead of the script"`
+	Upload                  string   `arg:"--upload,env:UPLOAD_FILES" placeholder:"SOURCE:DESTINATION" help:"Newline separated local:remote file pairs to copy to a VM before executing the script"`
+	Download                string   `arg:"--download,env:DOWNLOAD_FILES" placeholder:"SOURCE:DESTINATION" help:"Newline separated remote:local file pairs to copy from a VM after executing the script"`
+	LocalDirectory          string   `arg:"--local-dir,env:LOCAL_DIR" placeholder:"DIR" help:"Directory to resolve relative local paths of uploaded, downloaded and output files against"`
+	StdoutFile              string   `arg:"--stdout-file,env:STDOUT_FILE" placeholder:"FILE" help:"File to write the standard output of the script to"`
+	StderrFile              string   `arg:"--stderr-file,env:STDERR_FILE" placeholder:"FILE" help:"File to write the standard error output of the script to"`
+	ReportFile              string   `arg:"--report-file,env:REPORT_FILE" placeholder:"FILE" help:"File to write a json report of the execution to"`
+	Network                 string   `arg:"--network,env:NETWORK_NAME" placeholder:"NAME" help:"Name of a VM network (e.g. a Multus secondary network) to connect to. Defaults to the pod network"`
+	IPFamily                string   `arg:"--ip-family,env:IP_FAMILY" placeholder:"IPv4|IPv6" help:"Preferred IP family of the address to connect to"`
+	Service                 string   `arg:"--service,env:SERVICE_NAME" placeholder:"NAME" help:"Name of a Service in the VM namespace to connect through instead of the VM network"`
+	PortForward             string   `arg:"--port-forward,env:PORT_FORWARD" placeholder:"true|false" help:"Tunnels the connection through the portforward subresource of the VMI instead of connecting to the VM network"`
+	ReadinessGates          string   `arg:"--readiness-gates,env:READINESS_GATES" placeholder:"GATE[:TIMEOUT]" help:"Newline or comma separated gates (guest-agent|auth|probe) to wait for before executing the script, each with an optional timeout"`
+	ReadinessProbe          string   `arg:"--readiness-probe,env:READINESS_PROBE" placeholder:"SCRIPT" help:"Script which has to exit with 0 before executing the script"`
+	RestoreSnapshot         string   `arg:"--restore-snapshot,env:RESTORE_SNAPSHOT" placeholder:"never|on-failure|always" help:"Takes a VirtualMachineSnapshot before executing the script and restores it on failure or always"`
+	MaxAttempts             string   `arg:"--max-attempts,env:MAX_ATTEMPTS" placeholder:"N" help:"Maximum number of attempts to execute the script when the previous attempt failed with a retryable error"`
+	RetryBackoff            string   `arg:"--retry-backoff,env:RETRY_BACKOFF" placeholder:"DURATION" help:"Delay before the first retry, doubled with each next retry. Should be in a 3h2m1s format."`
+	RetryOnExitCodes        string   `arg:"--retry-on-exit-codes,env:RETRY_ON_EXIT_CODES" placeholder:"CODE,..." help:"Comma separated exit codes of the script which should be retried"`
+	RetryOnConnectionErrors string   `arg:"--retry-on-connection-errors,env:RETRY_ON_CONNECTION_ERRORS" placeholder:"true|false" help:"Retries connection errors"`
 	ConnectionSecretName    string   `arg:"--connectionSecretName,env:CONNECTION_SECRET_NAME" placeholder:"NAME" help:"Name of the connection secret (used only for validation)"`
 	Debug                   bool     `arg:"--debug" help:"Sets DEBUG log level"`
 	Command                 []string `arg:"positional" placeholder:"COMMAND" help:"Command to execute in a VM"`
+
+	vmNames        []string
+	uploads        []FileTransfer
+	downloads      []FileTransfer
+	readinessGates []ReadinessGate
+	retryExitCodes []int
 }
 
 func (c *CLIOptions) GetDebugLevel() zapcore.Level {
@@ -35,6 +108,37 @@ func (c *CLIOptions) GetDebugLevel() zapcore.Level {
 	return zapcore.InfoLevel
 }
 
+// GetVirtualMachineNames returns the names of the VMs set by vm-names option
+func (c *CLIOptions) GetVirtualMachineNames() []string {
+	return c.vmNames
+}
+
+func (c *CLIOptions) GetVirtualMachineSelector() string {
+	return c.VirtualMachineSelector
+}
+
+// HasMultipleVirtualMachines returns true if the action should be executed in VMs selected by name list or label selector
+func (c *CLIOptions) HasMultipleVirtualMachines() bool {
+	return len(c.vmNames) > 0 || c.VirtualMachineSelector != ""
+}
+
+func (c *CLIOptions) GetConcurrency() int {
+	if concurrency, err := strconv.Atoi(c.Concurrency); err == nil && concurrency > 0 {
+		return concurrency
+	}
+	return 1
+}
+
+// ForVirtualMachine returns a copy of the options which targets only the VM with the given name
+func (c *CLIOptions) ForVirtualMachine(vmName string) *CLIOptions {
+	options := *c
+	options.VirtualMachineName = vmName
+	options.VirtualMachineNames = ""
+	options.VirtualMachineSelector = ""
+	options.vmNames = nil
+	return &options
+}
+
 func (c *CLIOptions) GetVirtualMachineNamespace() string {
 	return c.VirtualMachineNamespace
 }
@@ -43,6 +147,109 @@ func (c *CLIOptions) GetScript() string {
 	return c.Script
 }
 
+func (c *CLIOptions) GetPlaybook() string {
+	return c.Playbook
+}
+
+func (c *CLIOptions) GetPlaybookFile() string {
+	return c.resolveLocalPath(c.PlaybookFile)
+}
+
+// HasPlaybook returns true if an Ansible playbook should be run instead of the script
+func (c *CLIOptions) HasPlaybook() bool {
+	return strings.TrimSpace(c.Playbook) != "" || c.PlaybookFile != ""
+}
+
+func (c *CLIOptions) GetNetwork() string {
+	return c.Network
+}
+
+func (c *CLIOptions) GetIPFamily() corev1.IPFamily {
+	for _, ipFamily := range []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol} {
+		if strings.EqualFold(c.IPFamily, string(ipFamily)) {
+			return ipFamily
+		}
+	}
+	return ""
+}
+
+func (c *CLIOptions) GetService() string {
+	return c.Service
+}
+
+func (c *CLIOptions) ShouldPortForward() bool {
+	return zutils.IsTrue(c.PortForward)
+}
+
+func (c *CLIOptions) GetStdoutFile() string {
+	return c.resolveLocalPath(c.StdoutFile)
+}
+
+func (c *CLIOptions) GetStderrFile() string {
+	return c.resolveLocalPath(c.StderrFile)
+}
+
+func (c *CLIOptions) GetReportFile() string {
+	return c.resolveLocalPath(c.ReportFile)
+}
+
+func (c *CLIOptions) GetUploads() []FileTransfer {
+	return c.uploads
+}
+
+func (c *CLIOptions) GetDownloads() []FileTransfer {
+	return c.downloads
+}
+
+func (c *CLIOptions) GetReadinessGates() []ReadinessGate {
+	return c.readinessGates
+}
+
+func (c *CLIOptions) GetReadinessProbe() string {
+	return c.ReadinessProbe
+}
+
+func (c *CLIOptions) GetRestoreSnapshotPolicy() constants.RestoreSnapshotPolicy {
+	if c.RestoreSnapshot == "" {
+		return constants.NeverRestoreSnapshot
+	}
+	return constants.RestoreSnapshotPolicy(c.RestoreSnapshot)
+}
+
+// ShouldTakeSnapshot returns true if a snapshot should be taken before executing the script
+func (c *CLIOptions) ShouldTakeSnapshot() bool {
+	return c.GetRestoreSnapshotPolicy() != constants.NeverRestoreSnapshot
+}
+
+func (c *CLIOptions) GetMaxAttempts() int {
+	if maxAttempts, err := strconv.Atoi(c.MaxAttempts); err == nil && maxAttempts > 0 {
+		return maxAttempts
+	}
+	return 1
+}
+
+func (c *CLIOptions) GetRetryBackoff() time.Duration {
+	if c.RetryBackoff != "" {
+		if backoff, err := time.ParseDuration(c.RetryBackoff); err == nil {
+			return backoff
+		}
+	}
+	return constants.DefaultRetryBackoff
+}
+
+func (c *CLIOptions) GetRetryOnExitCodes() []int {
+	return c.retryExitCodes
+}
+
+func (c *CLIOptions) ShouldRetryOnConnectionErrors() bool {
+	return zutils.IsTrue(c.RetryOnConnectionErrors)
+}
+
+// HasRemoteActions returns true if a connection to the VM is needed
+func (c *CLIOptions) HasRemoteActions() bool {
+	return c.GetScript() != "" || c.HasPlaybook() || len(c.uploads) > 0 || len(c.downloads) > 0
+}
+
 func (c *CLIOptions) GetScriptTimeout() time.Duration {
 	if c.Timeout != "" {
 		timeout, err := time.ParseDuration(c.Timeout)
@@ -58,6 +265,36 @@ func (c *CLIOptions) ShouldStop() bool {
 	return zutils.IsTrue(c.Stop)
 }
 
+func (c *CLIOptions) GetStopMode() constants.StopMode {
+	if c.StopMode == "" {
+		return constants.ACPIStopMode
+	}
+	return constants.StopMode(c.StopMode)
+}
+
+func (c *CLIOptions) GetShutdownCommand() string {
+	return c.ShutdownCommand
+}
+
+// GetStopGracePeriod returns the time to wait for the guest to shut down. Zero means the VM is forced to stop right away.
+func (c *CLIOptions) GetStopGracePeriod() time.Duration {
+	if c.StopGracePeriod != "" {
+		if gracePeriod, err := time.ParseDuration(c.StopGracePeriod); err == nil {
+			return gracePeriod
+		}
+	}
+	return constants.DefaultStopGracePeriod
+}
+
+func (c *CLIOptions) GetStopTimeout() time.Duration {
+	if c.StopTimeout != "" {
+		if timeout, err := time.ParseDuration(c.StopTimeout); err == nil {
+			return timeout
+		}
+	}
+	return constants.DefaultStopTimeout
+}
+
 func (c *CLIOptions) ShouldDelete() bool {
 	return zutils.IsTrue(c.Delete)
 }
@@ -65,7 +302,7 @@ func (c *CLIOptions) ShouldDelete() bool {
 func (c *CLIOptions) Init() error {
 	c.trimSpaces()
 
-	if err := c.validateName(); err != nil {
+	if err := c.resolveVirtualMachines(); err != nil {
 		return err
 	}
 
@@ -73,18 +310,42 @@ func (c *CLIOptions) Init() error {
 		return err
 	}
 
+	if err := c.resolveFileTransfers(); err != nil {
+		return err
+	}
+
 	if err := c.resolveExecutionScript(); err != nil {
 		return err
 	}
 
+	if err := c.validateMultipleVirtualMachines(); err != nil {
+		return err
+	}
+
+	if err := c.resolveReadinessGates(); err != nil {
+		return err
+	}
+
 	if err := c.validateConnectionSecretName(); err != nil {
 		return err
 	}
 
+	if err := c.validateConnectionTarget(); err != nil {
+		return err
+	}
+
 	if err := c.validateTimeout(); err != nil {
 		return err
 	}
 
+	if err := c.resolveRetryPolicy(); err != nil {
+		return err
+	}
+
+	if err := c.validateStopOptions(); err != nil {
+		return err
+	}
+
 	if err := c.validateValues(); err != nil {
 		return err
 	}
@@ -3,10 +3,12 @@ package parse_test
 import (
 	"time"
 
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/constants"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/execute-in-vm/pkg/utils/parse"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
 
 	"reflect"
 )
@@ -25,11 +27,72 @@ var _ = Describe("CLIOptions", func() {
 		Entry("no vm", "missing value for vm-name option", &parse.CLIOptions{
 			VirtualMachineNamespace: defaultNS,
 		}),
+		Entry("vm name and vm names", "only one of vm-name|vm-names|vm-selector options is allowed", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNames:     "vm1,vm2",
+			VirtualMachineNamespace: defaultNS,
+		}),
+		Entry("vm names and vm selector", "only one of vm-name|vm-names|vm-selector options is allowed", &parse.CLIOptions{
+			VirtualMachineNames:     "vm1,vm2",
+			VirtualMachineSelector:  "app=test",
+			VirtualMachineNamespace: defaultNS,
+		}),
+		Entry("invalid vm names", "invalid vm-names option value 'no dns 1123': a lowercase RFC 1123 subdomain must consist of", &parse.CLIOptions{
+			VirtualMachineNames:     "vm1\nno dns 1123",
+			VirtualMachineNamespace: defaultNS,
+		}),
+		Entry("invalid vm selector", "invalid vm-selector option value 'app in (test': ", &parse.CLIOptions{
+			VirtualMachineSelector:  "app in (test",
+			VirtualMachineNamespace: defaultNS,
+		}),
+		Entry("invalid concurrency", "invalid option concurrency -1, should be a positive number", &parse.CLIOptions{
+			VirtualMachineNames:     "vm1,vm2",
+			VirtualMachineNamespace: defaultNS,
+			Concurrency:             "-1",
+		}),
+		Entry("download with multiple vms", "download option is not supported with multiple VMs", &parse.CLIOptions{
+			VirtualMachineSelector:  "app=test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ConnectionSecretName:    "my-secret",
+			Download:                "/tmp/report.xml:report.xml",
+		}),
+		Entry("stdout file with multiple vms", "stdout-file option is not supported with multiple VMs", &parse.CLIOptions{
+			VirtualMachineNames:     "vm1,vm2",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ConnectionSecretName:    "my-secret",
+			StdoutFile:              "stdout.txt",
+		}),
+		Entry("playbook and playbook file", "only one of playbook|playbook-file options is allowed", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Playbook:                "- hosts: all",
+			PlaybookFile:            "playbook.yaml",
+		}),
+		Entry("script and playbook", "only one of command|script|playbook|playbook-file options is allowed", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			PlaybookFile:            "playbook.yaml",
+		}),
+		Entry("playbook and port forward", "port-forward option is not supported with a playbook", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			ConnectionSecretName:    "my-secret",
+			Playbook:                "- hosts: all",
+			PortForward:             "true",
+		}),
+		Entry("playbook without connection secret", "connection secret should not be empty", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Playbook:                "- hosts: all",
+		}),
 		Entry("invalid vm name", "vm-name is not a valid name: a lowercase RFC 1123 subdomain must consist of", &parse.CLIOptions{
 			VirtualMachineName:      "no dns 1123",
 			VirtualMachineNamespace: defaultNS,
 		}),
-		Entry("no script or command", "no action was specified: at least one of the following options is required: command|script|stop|delete", &parse.CLIOptions{
+		Entry("no script or command", "no action was specified: at least one of the following options is required: command|script|upload|download|stop|delete", &parse.CLIOptions{
 			VirtualMachineName:      "test",
 			VirtualMachineNamespace: defaultNS,
 		}),
@@ -77,6 +140,160 @@ var _ = Describe("CLIOptions", func() {
 			Delete:                  "yes",
 			ConnectionSecretName:    "my-secret",
 		}),
+		Entry("network and service", "only one of network|service|port-forward options is allowed", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			Network:                 "secondary",
+			Service:                 "vm-ssh",
+			ConnectionSecretName:    "my-secret",
+		}),
+		Entry("service and port forward", "only one of network|service|port-forward options is allowed", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			Service:                 "vm-ssh",
+			PortForward:             "true",
+			ConnectionSecretName:    "my-secret",
+		}),
+		Entry("invalid port forward", "invalid option port-forward yes, only true|false is allowed", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			PortForward:             "yes",
+			ConnectionSecretName:    "my-secret",
+		}),
+		Entry("invalid readiness gate", "invalid readiness-gates option value 'reboot': only guest-agent|auth|probe gates are allowed", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ReadinessGates:          "auth\nreboot",
+			ConnectionSecretName:    "my-secret",
+		}),
+		Entry("invalid readiness gate timeout", "invalid readiness-gates option value 'auth:soon': could not parse timeout", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ReadinessGates:          "auth:soon",
+			ConnectionSecretName:    "my-secret",
+		}),
+		Entry("probe gate without readiness probe", "probe gate requires readiness-probe option", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ReadinessGates:          "probe:5m",
+			ConnectionSecretName:    "my-secret",
+		}),
+		Entry("invalid max attempts", "invalid option max-attempts 0, should be a number between 1 and 100", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ConnectionSecretName:    "my-secret",
+			MaxAttempts:             "0",
+		}),
+		Entry("too many max attempts", "invalid option max-attempts 1000, should be a number between 1 and 100", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ConnectionSecretName:    "my-secret",
+			MaxAttempts:             "1000",
+		}),
+		Entry("invalid retry backoff", "invalid option retry-backoff 10, should be a non-negative duration in a 3h2m1s format", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ConnectionSecretName:    "my-secret",
+			RetryBackoff:            "10",
+		}),
+		Entry("invalid retry exit code", "invalid option retry-on-exit-codes value 'a', should be a number", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ConnectionSecretName:    "my-secret",
+			RetryOnExitCodes:        "1,a",
+		}),
+		Entry("invalid retry on connection errors", "invalid option retry-on-connection-errors maybe, only true|false is allowed", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ConnectionSecretName:    "my-secret",
+			RetryOnConnectionErrors: "maybe",
+		}),
+		Entry("invalid restore snapshot", "invalid option restore-snapshot sometimes, only never|on-failure|always is allowed", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ConnectionSecretName:    "my-secret",
+			RestoreSnapshot:         "sometimes",
+		}),
+		Entry("invalid stop mode", "invalid option stop-mode poweroff, only acpi|guest-command is allowed", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Stop:                    "true",
+			StopMode:                "poweroff",
+		}),
+		Entry("guest command stop mode without connection", "guest-command stop mode requires a connection to the VM", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Stop:                    "true",
+			StopMode:                "guest-command",
+		}),
+		Entry("shutdown command in acpi stop mode", "shutdown-command option is supported only in the guest-command stop mode", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Stop:                    "true",
+			ShutdownCommand:         "poweroff",
+		}),
+		Entry("invalid stop grace period", "invalid option stop-grace-period -1m, should be a non-negative duration in a 3h2m1s format", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Stop:                    "true",
+			StopGracePeriod:         "-1m",
+		}),
+		Entry("invalid stop timeout", "invalid option stop-timeout forever, should be a non-negative duration in a 3h2m1s format", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Stop:                    "true",
+			StopTimeout:             "forever",
+		}),
+		Entry("stop timeout shorter than grace period", "stop-timeout option should be longer than stop-grace-period option", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Stop:                    "true",
+			StopGracePeriod:         "2m",
+			StopTimeout:             "1m",
+		}),
+		Entry("invalid ip family", "invalid option ip-family IPv5, only IPv4|IPv6 is allowed", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			IPFamily:                "IPv5",
+			ConnectionSecretName:    "my-secret",
+		}),
+		Entry("invalid service", "service is not a valid name: a DNS-1035 label must consist of", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			Service:                 "1-vm.ssh",
+			ConnectionSecretName:    "my-secret",
+		}),
+		Entry("upload without connection secret", "connection secret should not be empty", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Upload:                  "artifact.tar:/tmp/artifact.tar",
+		}),
+		Entry("invalid upload", "invalid upload option value 'artifact.tar': should be in SOURCE:DESTINATION format", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Upload:                  "artifact.tar",
+			ConnectionSecretName:    "my-secret",
+		}),
+		Entry("invalid download", "invalid download option value '/tmp/report.xml:': should be in SOURCE:DESTINATION format", &parse.CLIOptions{
+			VirtualMachineName:      "test",
+			VirtualMachineNamespace: defaultNS,
+			Download:                "/tmp/report.xml:",
+			ConnectionSecretName:    "my-secret",
+		}),
 	)
 	//
 	DescribeTable("Parses and returns correct values", func(options *parse.CLIOptions, expectedOptions map[string]interface{}) {
@@ -99,6 +316,52 @@ var _ = Describe("CLIOptions", func() {
 			"GetScriptTimeout":           0 * time.Second,
 			"ShouldStop":                 false,
 			"ShouldDelete":               false,
+			"HasMultipleVirtualMachines": false,
+		}),
+		Entry("handles vm names", &parse.CLIOptions{
+			VirtualMachineNames:     "vm1, vm2\nvm3,vm1\n",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ConnectionSecretName:    "my-secret",
+			Concurrency:             "2",
+		}, map[string]interface{}{
+			"GetVirtualMachineNames":     []string{"vm1", "vm2", "vm3"},
+			"GetVirtualMachineSelector":  "",
+			"HasMultipleVirtualMachines": true,
+			"GetConcurrency":             2,
+		}),
+		Entry("handles vm selector", &parse.CLIOptions{
+			VirtualMachineSelector:  " app=test,tier!=db ",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetVirtualMachineSelector":  "app=test,tier!=db",
+			"HasMultipleVirtualMachines": true,
+			"GetConcurrency":             1,
+		}),
+		Entry("handles playbook", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			Playbook:                "- hosts: all",
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetScript":        "",
+			"GetPlaybook":      "- hosts: all",
+			"GetPlaybookFile":  "",
+			"HasPlaybook":      true,
+			"HasRemoteActions": true,
+		}),
+		Entry("handles playbook file", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			PlaybookFile:            " playbooks/site.yaml ",
+			LocalDirectory:          "/workspace/data",
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetPlaybookFile":  "/workspace/data/playbooks/site.yaml",
+			"HasPlaybook":      true,
+			"HasRemoteActions": true,
 		}),
 		Entry("handles Script cli arguments", &parse.CLIOptions{
 			VirtualMachineName:      "vm",
@@ -117,6 +380,32 @@ var _ = Describe("CLIOptions", func() {
 			"ShouldStop":                 true,
 			"ShouldDelete":               false,
 		}),
+		Entry("handles default stop options", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			Stop:                    "true",
+		}, map[string]interface{}{
+			"GetStopMode":        constants.ACPIStopMode,
+			"GetShutdownCommand": "",
+			"GetStopGracePeriod": 5 * time.Minute,
+			"GetStopTimeout":     10 * time.Minute,
+		}),
+		Entry("handles stop options", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			Stop:                    "true",
+			StopMode:                " guest-command ",
+			ShutdownCommand:         " poweroff ",
+			StopGracePeriod:         "0s",
+			StopTimeout:             "2m",
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetStopMode":        constants.GuestCommandStopMode,
+			"GetShutdownCommand": "poweroff",
+			"GetStopGracePeriod": 0 * time.Second,
+			"GetStopTimeout":     2 * time.Minute,
+		}),
 		Entry("handles simple Command cli arguments", &parse.CLIOptions{
 			VirtualMachineName:      "vm",
 			VirtualMachineNamespace: defaultNS,
@@ -143,6 +432,169 @@ var _ = Describe("CLIOptions", func() {
 			"ShouldStop":                 true,
 			"ShouldDelete":               true,
 		}),
+		Entry("handles connection target", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			Network:                 " secondary ",
+			IPFamily:                "ipv6",
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetNetwork":        "secondary",
+			"GetIPFamily":       corev1.IPv6Protocol,
+			"GetService":        "",
+			"ShouldPortForward": false,
+		}),
+		Entry("handles service", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			Service:                 "vm-ssh",
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetNetwork":        "",
+			"GetIPFamily":       corev1.IPFamily(""),
+			"GetService":        "vm-ssh",
+			"ShouldPortForward": false,
+		}),
+		Entry("handles readiness gates", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ReadinessGates:          "guest-agent:5m, auth\n probe : 1m30s \n",
+			ReadinessProbe:          "cloud-init status --wait",
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetReadinessGates": []parse.ReadinessGate{
+				{Type: constants.GuestAgentReadinessGate, Timeout: 5 * time.Minute},
+				{Type: constants.AuthReadinessGate},
+				{Type: constants.ProbeReadinessGate, Timeout: 90 * time.Second},
+			},
+			"GetReadinessProbe": "cloud-init status --wait",
+		}),
+		Entry("adds probe gate for readiness probe", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ReadinessGates:          "auth",
+			ReadinessProbe:          "test -f /var/lib/cloud/instance/boot-finished",
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetReadinessGates": []parse.ReadinessGate{
+				{Type: constants.AuthReadinessGate},
+				{Type: constants.ProbeReadinessGate},
+			},
+		}),
+		Entry("handles retry policy", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			MaxAttempts:             " 3 ",
+			RetryBackoff:            "30s",
+			RetryOnExitCodes:        "1, 255,",
+			RetryOnConnectionErrors: "true",
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetMaxAttempts":                3,
+			"GetRetryBackoff":               30 * time.Second,
+			"GetRetryOnExitCodes":           []int{1, 255},
+			"ShouldRetryOnConnectionErrors": true,
+		}),
+		Entry("handles restore snapshot", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			RestoreSnapshot:         " on-failure ",
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetRestoreSnapshotPolicy": constants.OnFailureRestoreSnapshot,
+			"ShouldTakeSnapshot":       true,
+		}),
+		Entry("handles disabled restore snapshot", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetRestoreSnapshotPolicy": constants.NeverRestoreSnapshot,
+			"ShouldTakeSnapshot":       false,
+		}),
+		Entry("handles default retry policy", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetMaxAttempts":                1,
+			"GetRetryBackoff":               10 * time.Second,
+			"GetRetryOnExitCodes":           []int(nil),
+			"ShouldRetryOnConnectionErrors": false,
+		}),
+		Entry("handles port forward", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			PortForward:             "true",
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetNetwork":        "",
+			"GetService":        "",
+			"ShouldPortForward": true,
+		}),
+		Entry("handles file transfers", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			Upload:                  "build/artifact.tar:/tmp/artifact.tar\n\n /etc/hosts : /tmp/hosts \n",
+			Download:                "/var/log/report.xml:reports/report.xml",
+			LocalDirectory:          "/workspace/data",
+			StdoutFile:              "logs/stdout.log",
+			ReportFile:              "reports/report.json",
+			StderrFile:              "/tmp/stderr.log",
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetScript":     "",
+			"GetStdoutFile": "/workspace/data/logs/stdout.log",
+			"GetReportFile": "/workspace/data/reports/report.json",
+			"GetStderrFile": "/tmp/stderr.log",
+			"GetUploads": []parse.FileTransfer{
+				{Source: "/workspace/data/build/artifact.tar", Destination: "/tmp/artifact.tar"},
+				{Source: "/etc/hosts", Destination: "/tmp/hosts"},
+			},
+			"GetDownloads": []parse.FileTransfer{
+				{Source: "/var/log/report.xml", Destination: "/workspace/data/reports/report.xml"},
+			},
+			"HasRemoteActions": true,
+		}),
+		Entry("handles file transfers without local directory", &parse.CLIOptions{
+			VirtualMachineName:      "vm",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			Download:                "/var/log/report.xml:report.xml",
+			ConnectionSecretName:    "my-secret",
+		}, map[string]interface{}{
+			"GetUploads": []parse.FileTransfer(nil),
+			"GetDownloads": []parse.FileTransfer{
+				{Source: "/var/log/report.xml", Destination: "report.xml"},
+			},
+			"HasRemoteActions": true,
+		}),
 	)
 
+	It("targets a single vm", func() {
+		options := &parse.CLIOptions{
+			VirtualMachineNames:     "vm1,vm2",
+			VirtualMachineNamespace: defaultNS,
+			Script:                  script,
+			ConnectionSecretName:    "my-secret",
+			Upload:                  "artifact.tar:/tmp/artifact.tar",
+		}
+		Expect(options.Init()).Should(Succeed())
+
+		vmOptions := options.ForVirtualMachine("vm2")
+		Expect(vmOptions.VirtualMachineName).To(Equal("vm2"))
+		Expect(vmOptions.HasMultipleVirtualMachines()).To(BeFalse())
+		Expect(vmOptions.GetUploads()).To(Equal(options.GetUploads()))
+		Expect(vmOptions.GetScript()).To(Equal(script))
+		Expect(options.HasMultipleVirtualMachines()).To(BeTrue())
+	})
 })
@@ -5,24 +5,106 @@ import (
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/env"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zconstants"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zerrors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/validation"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
 
 func (c *CLIOptions) trimSpaces() {
 	c.VirtualMachineNamespace = strings.TrimSpace(c.VirtualMachineNamespace)
+	c.VirtualMachineSelector = strings.TrimSpace(c.VirtualMachineSelector)
+	c.Concurrency = strings.TrimSpace(c.Concurrency)
+	c.LocalDirectory = strings.TrimSpace(c.LocalDirectory)
+	c.PlaybookFile = strings.TrimSpace(c.PlaybookFile)
+	c.StdoutFile = strings.TrimSpace(c.StdoutFile)
+	c.StderrFile = strings.TrimSpace(c.StderrFile)
+	c.ReportFile = strings.TrimSpace(c.ReportFile)
+	c.Network = strings.TrimSpace(c.Network)
+	c.IPFamily = strings.TrimSpace(c.IPFamily)
+	c.Service = strings.TrimSpace(c.Service)
+	c.RestoreSnapshot = strings.TrimSpace(c.RestoreSnapshot)
+	c.MaxAttempts = strings.TrimSpace(c.MaxAttempts)
+	c.RetryBackoff = strings.TrimSpace(c.RetryBackoff)
+	c.StopMode = strings.TrimSpace(c.StopMode)
+	c.ShutdownCommand = strings.TrimSpace(c.ShutdownCommand)
+	c.StopGracePeriod = strings.TrimSpace(c.StopGracePeriod)
+	c.StopTimeout = strings.TrimSpace(c.StopTimeout)
 }
 
-func (c *CLIOptions) validateName() error {
-	if c.VirtualMachineName == "" {
-		return zerrors.NewMissingRequiredError("missing value for %v option", vmNameOptionName)
+func (c *CLIOptions) resolveVirtualMachines() error {
+	c.vmNames = nil
+	seenVMNames := map[string]bool{}
+	for _, vmName := range strings.FieldsFunc(c.VirtualMachineNames, func(r rune) bool { return r == '\n' || r == ',' }) {
+		if vmName = strings.TrimSpace(vmName); vmName != "" && !seenVMNames[vmName] {
+			seenVMNames[vmName] = true
+			c.vmNames = append(c.vmNames, vmName)
+		}
+	}
+
+	targets := 0
+	for _, isSet := range []bool{c.VirtualMachineName != "", len(c.vmNames) > 0, c.VirtualMachineSelector != ""} {
+		if isSet {
+			targets++
+		}
+	}
+	if targets == 0 {
+		return zerrors.NewMissingRequiredError("missing value for %v option: one of %v|%v|%v options is required",
+			vmNameOptionName, vmNameOptionName, vmNamesOptionName, vmSelectorOptionName)
+	}
+	if targets > 1 {
+		return zerrors.NewMissingRequiredError("only one of %v|%v|%v options is allowed", vmNameOptionName, vmNamesOptionName, vmSelectorOptionName)
 	}
 
-	errs := validation.IsDNS1123Subdomain(c.VirtualMachineName)
-	if len(errs) > 0 {
-		return zerrors.NewMissingRequiredError("%v is not a valid name: %v", vmNameOptionName, strings.Join(errs, ";"))
+	if c.VirtualMachineName != "" {
+		if errs := validation.IsDNS1123Subdomain(c.VirtualMachineName); len(errs) > 0 {
+			return zerrors.NewMissingRequiredError("%v is not a valid name: %v", vmNameOptionName, strings.Join(errs, ";"))
+		}
 	}
+
+	for _, vmName := range c.vmNames {
+		if errs := validation.IsDNS1123Subdomain(vmName); len(errs) > 0 {
+			return zerrors.NewMissingRequiredError("invalid %v option value '%v': %v", vmNamesOptionName, vmName, strings.Join(errs, ";"))
+		}
+	}
+
+	if c.VirtualMachineSelector != "" {
+		if _, err := labels.Parse(c.VirtualMachineSelector); err != nil {
+			return zerrors.NewMissingRequiredError("invalid %v option value '%v': %v", vmSelectorOptionName, c.VirtualMachineSelector, err.Error())
+		}
+	}
+
+	if c.Concurrency != "" {
+		if concurrency, err := strconv.Atoi(c.Concurrency); err != nil || concurrency < 1 {
+			return zerrors.NewSoftError("invalid option %v %v, should be a positive number", concurrencyOptionName, c.Concurrency)
+		}
+	}
+
+	return nil
+}
+
+// validateMultipleVirtualMachines rejects options which would write the same local files for each VM
+func (c *CLIOptions) validateMultipleVirtualMachines() error {
+	if !c.HasMultipleVirtualMachines() {
+		return nil
+	}
+
+	for _, option := range []struct {
+		name  string
+		isSet bool
+	}{
+		{downloadOptionName, len(c.downloads) > 0},
+		{stdoutFileOptionName, c.StdoutFile != ""},
+		{stderrFileOptionName, c.StderrFile != ""},
+	} {
+		if option.isSet {
+			return zerrors.NewMissingRequiredError("%v option is not supported with multiple VMs", option.name)
+		}
+	}
+
 	return nil
 }
 
@@ -44,15 +126,23 @@ func (c *CLIOptions) resolveDefaultNamespaces() error {
 func (c *CLIOptions) resolveExecutionScript() error {
 	command := strings.Join(c.Command, " ")
 
+	if strings.TrimSpace(c.Playbook) != "" && c.PlaybookFile != "" {
+		return zerrors.NewMissingRequiredError("only one of %v|%v options is allowed", playbookOptionName, playbookFileOptionName)
+	}
+
+	if c.HasPlaybook() && (c.GetScript() != "" || strings.TrimSpace(command) != "") {
+		return zerrors.NewMissingRequiredError("only one of %v|%v|%v|%v options is allowed", commandOptionName, scriptOptionName, playbookOptionName, playbookFileOptionName)
+	}
+
 	if c.GetScript() != "" {
 		if command != "" {
 			return zerrors.NewMissingRequiredError("only one of %v|%v options is allowed", commandOptionName, scriptOptionName)
 		}
 		return nil
 	}
-	if !c.ShouldStop() && !c.ShouldDelete() && strings.TrimSpace(command) == "" {
-		return zerrors.NewMissingRequiredError("no action was specified: at least one of the following options is required: %v|%v|%v|%v",
-			commandOptionName, scriptOptionName, stopOptionName, deleteOptionName)
+	if !c.ShouldStop() && !c.ShouldDelete() && strings.TrimSpace(command) == "" && !c.HasRemoteActions() {
+		return zerrors.NewMissingRequiredError("no action was specified: at least one of the following options is required: %v|%v|%v|%v|%v|%v|%v|%v",
+			commandOptionName, scriptOptionName, uploadOptionName, downloadOptionName, stopOptionName, deleteOptionName, playbookOptionName, playbookFileOptionName)
 	}
 
 	c.Script = command
@@ -61,10 +151,101 @@ func (c *CLIOptions) resolveExecutionScript() error {
 
 }
 
+func (c *CLIOptions) resolveFileTransfers() error {
+	var err error
+
+	if c.uploads, err = c.parseFileTransfers(uploadOptionName, c.Upload, false); err != nil {
+		return err
+	}
+
+	if c.downloads, err = c.parseFileTransfers(downloadOptionName, c.Download, true); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *CLIOptions) parseFileTransfers(optionName, value string, toLocal bool) ([]FileTransfer, error) {
+	var transfers []FileTransfer
+
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		paths := strings.SplitN(line, ":", 2)
+		if len(paths) != 2 || strings.TrimSpace(paths[0]) == "" || strings.TrimSpace(paths[1]) == "" {
+			return nil, zerrors.NewMissingRequiredError("invalid %v option value '%v': should be in SOURCE:DESTINATION format", optionName, line)
+		}
+
+		transfer := FileTransfer{Source: strings.TrimSpace(paths[0]), Destination: strings.TrimSpace(paths[1])}
+		if toLocal {
+			transfer.Destination = c.resolveLocalPath(transfer.Destination)
+		} else {
+			transfer.Source = c.resolveLocalPath(transfer.Source)
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, nil
+}
+
+func (c *CLIOptions) resolveReadinessGates() error {
+	c.readinessGates = nil
+	hasProbeGate := false
+
+	for _, value := range strings.FieldsFunc(c.ReadinessGates, func(r rune) bool { return r == '\n' || r == ',' }) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		fields := strings.SplitN(value, ":", 2)
+		gate := ReadinessGate{Type: constants.ReadinessGateType(strings.TrimSpace(fields[0]))}
+
+		switch gate.Type {
+		case constants.GuestAgentReadinessGate, constants.AuthReadinessGate:
+		case constants.ProbeReadinessGate:
+			hasProbeGate = true
+		default:
+			return zerrors.NewMissingRequiredError("invalid %v option value '%v': only %v|%v|%v gates are allowed", readinessGatesOptionName, value,
+				constants.GuestAgentReadinessGate, constants.AuthReadinessGate, constants.ProbeReadinessGate)
+		}
+
+		if len(fields) == 2 {
+			timeout, err := time.ParseDuration(strings.TrimSpace(fields[1]))
+			if err != nil {
+				return zerrors.NewMissingRequiredError("invalid %v option value '%v': could not parse timeout: %v", readinessGatesOptionName, value, err)
+			}
+			gate.Timeout = timeout
+		}
+
+		c.readinessGates = append(c.readinessGates, gate)
+	}
+
+	if strings.TrimSpace(c.ReadinessProbe) != "" {
+		if !hasProbeGate {
+			c.readinessGates = append(c.readinessGates, ReadinessGate{Type: constants.ProbeReadinessGate})
+		}
+	} else if hasProbeGate {
+		return zerrors.NewMissingRequiredError("%v gate requires %v option", constants.ProbeReadinessGate, readinessProbeOptionName)
+	}
+
+	return nil
+}
+
+func (c *CLIOptions) resolveLocalPath(localPath string) string {
+	if localPath == "" || c.LocalDirectory == "" || filepath.IsAbs(localPath) {
+		return localPath
+	}
+	return filepath.Join(c.LocalDirectory, localPath)
+}
+
 func (c *CLIOptions) validateConnectionSecretName() error {
 	command := strings.Join(c.Command, " ")
 
-	if c.GetScript() != "" || strings.TrimSpace(command) != "" {
+	if c.HasRemoteActions() || strings.TrimSpace(command) != "" {
 		if c.ConnectionSecretName == "" || c.ConnectionSecretName == constants.EmptyConnectionSecretName {
 			return zerrors.NewMissingRequiredError("connection secret should not be empty")
 		}
@@ -79,6 +260,34 @@ func (c *CLIOptions) validateConnectionSecretName() error {
 
 }
 
+func (c *CLIOptions) validateConnectionTarget() error {
+	targets := 0
+	for _, isSet := range []bool{c.Network != "", c.Service != "", c.ShouldPortForward()} {
+		if isSet {
+			targets++
+		}
+	}
+	if targets > 1 {
+		return zerrors.NewMissingRequiredError("only one of %v|%v|%v options is allowed", networkOptionName, serviceOptionName, portForwardOptionName)
+	}
+
+	if c.HasPlaybook() && c.ShouldPortForward() {
+		return zerrors.NewMissingRequiredError("%v option is not supported with a playbook", portForwardOptionName)
+	}
+
+	if c.IPFamily != "" && c.GetIPFamily() == "" {
+		return zerrors.NewSoftError("invalid option %v %v, only %v|%v is allowed", ipFamilyOptionName, c.IPFamily, corev1.IPv4Protocol, corev1.IPv6Protocol)
+	}
+
+	if c.Service != "" {
+		if errs := validation.IsDNS1035Label(c.Service); len(errs) > 0 {
+			return zerrors.NewMissingRequiredError("%v is not a valid name: %v", serviceOptionName, strings.Join(errs, ";"))
+		}
+	}
+
+	return nil
+}
+
 func (c *CLIOptions) validateTimeout() error {
 	if c.Timeout != "" {
 		_, err := time.ParseDuration(c.Timeout)
@@ -90,6 +299,72 @@ func (c *CLIOptions) validateTimeout() error {
 
 }
 
+func (c *CLIOptions) resolveRetryPolicy() error {
+	if c.MaxAttempts != "" {
+		if maxAttempts, err := strconv.Atoi(c.MaxAttempts); err != nil || maxAttempts < 1 || maxAttempts > constants.MaxAttempts {
+			return zerrors.NewSoftError("invalid option %v %v, should be a number between 1 and %v", maxAttemptsOptionName, c.MaxAttempts, constants.MaxAttempts)
+		}
+	}
+
+	if c.RetryBackoff != "" {
+		if backoff, err := time.ParseDuration(c.RetryBackoff); err != nil || backoff < 0 {
+			return zerrors.NewSoftError("invalid option %v %v, should be a non-negative duration in a 3h2m1s format", retryBackoffOptionName, c.RetryBackoff)
+		}
+	}
+
+	c.retryExitCodes = nil
+	for _, value := range strings.Split(c.RetryOnExitCodes, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		exitCode, err := strconv.Atoi(value)
+		if err != nil {
+			return zerrors.NewSoftError("invalid option %v value '%v', should be a number", retryOnExitCodesOptionName, value)
+		}
+		c.retryExitCodes = append(c.retryExitCodes, exitCode)
+	}
+
+	return nil
+}
+
+func (c *CLIOptions) validateStopOptions() error {
+	switch c.GetStopMode() {
+	case constants.ACPIStopMode:
+		if c.ShutdownCommand != "" {
+			return zerrors.NewMissingRequiredError("%v option is supported only in the %v stop mode", shutdownCommandOptionName, constants.GuestCommandStopMode)
+		}
+	case constants.GuestCommandStopMode:
+		// the command is sent over the connection used for the script
+		if !c.HasRemoteActions() {
+			return zerrors.NewMissingRequiredError("%v stop mode requires a connection to the VM: one of %v|%v|%v|%v|%v|%v options is required", constants.GuestCommandStopMode,
+				commandOptionName, scriptOptionName, playbookOptionName, playbookFileOptionName, uploadOptionName, downloadOptionName)
+		}
+	default:
+		return zerrors.NewSoftError("invalid option %v %v, only %v|%v is allowed", stopModeOptionName, c.StopMode, constants.ACPIStopMode, constants.GuestCommandStopMode)
+	}
+
+	for _, option := range []struct {
+		name  string
+		value string
+	}{
+		{stopGracePeriodOptionName, c.StopGracePeriod},
+		{stopTimeoutOptionName, c.StopTimeout},
+	} {
+		if option.value != "" {
+			if duration, err := time.ParseDuration(option.value); err != nil || duration < 0 {
+				return zerrors.NewSoftError("invalid option %v %v, should be a non-negative duration in a 3h2m1s format", option.name, option.value)
+			}
+		}
+	}
+
+	if c.GetStopTimeout() <= c.GetStopGracePeriod() {
+		return zerrors.NewSoftError("%v option should be longer than %v option", stopTimeoutOptionName, stopGracePeriodOptionName)
+	}
+
+	return nil
+}
+
 func (c *CLIOptions) validateValues() error {
 	allowedValues := map[string]bool{
 		"":               true,
@@ -105,6 +380,21 @@ func (c *CLIOptions) validateValues() error {
 		return zerrors.NewSoftError("invalid option delete %v, only true|false is allowed", c.Delete)
 	}
 
+	switch c.GetRestoreSnapshotPolicy() {
+	case constants.NeverRestoreSnapshot, constants.OnFailureRestoreSnapshot, constants.AlwaysRestoreSnapshot:
+	default:
+		return zerrors.NewSoftError("invalid option %v %v, only %v|%v|%v is allowed", restoreSnapshotOptionName, c.RestoreSnapshot,
+			constants.NeverRestoreSnapshot, constants.OnFailureRestoreSnapshot, constants.AlwaysRestoreSnapshot)
+	}
+
+	if !allowedValues[c.RetryOnConnectionErrors] {
+		return zerrors.NewSoftError("invalid option %v %v, only true|false is allowed", retryOnConnectionErrorsOptionName, c.RetryOnConnectionErrors)
+	}
+
+	if !allowedValues[c.PortForward] {
+		return zerrors.NewSoftError("invalid option %v %v, only true|false is allowed", portForwardOptionName, c.PortForward)
+	}
+
 	return nil
 
 }
@@ -0,0 +1,194 @@
+package utilstest
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/onsi/gomega"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHCommandHandler executes a command received by SSHServer and returns its exit status
+type SSHCommandHandler func(command string, stdout, stderr io.Writer, signals <-chan string) uint32
+
+type SSHServerOptions struct {
+	User           string
+	HostPrivateKey string
+	AuthorizedKey  string
+	// Password enables password authentication
+	Password string
+	// KeyboardInteractivePassword enables keyboard-interactive authentication
+	KeyboardInteractivePassword string
+	Handler                     SSHCommandHandler
+}
+
+// SSHServer is an in-process stand-in of a guest ssh server
+type SSHServer struct {
+	options  SSHServerOptions
+	config   *ssh.ServerConfig
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	lock     sync.Mutex
+	commands []string
+}
+
+func NewSSHServer(options SSHServerOptions) *SSHServer {
+	hostKey, err := ssh.ParsePrivateKey([]byte(options.HostPrivateKey))
+	gomega.Expect(err).Should(gomega.Succeed())
+
+	config := &ssh.ServerConfig{}
+	config.AddHostKey(hostKey)
+
+	if options.AuthorizedKey != "" {
+		authorizedKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(options.AuthorizedKey))
+		gomega.Expect(err).Should(gomega.Succeed())
+
+		config.PublicKeyCallback = func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == options.User && bytes.Equal(key.Marshal(), authorizedKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, ssh.ErrNoAuth
+		}
+	}
+
+	if options.Password != "" {
+		config.PasswordCallback = func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == options.User && string(password) == options.Password {
+				return nil, nil
+			}
+			return nil, ssh.ErrNoAuth
+		}
+	}
+
+	if options.KeyboardInteractivePassword != "" {
+		config.KeyboardInteractiveCallback = func(conn ssh.ConnMetadata, client ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			answers, err := client(conn.User(), "", []string{"Password: "}, []bool{false})
+			if err == nil && conn.User() == options.User && len(answers) == 1 && answers[0] == options.KeyboardInteractivePassword {
+				return nil, nil
+			}
+			return nil, ssh.ErrNoAuth
+		}
+	}
+
+	return &SSHServer{options: options, config: config}
+}
+
+// Start listens on a random local port and returns it
+func (s *SSHServer) Start() int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	gomega.Expect(err).Should(gomega.Succeed())
+	s.listener = listener
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.handleConnection(conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *SSHServer) Close() {
+	if s.listener != nil {
+		_ = s.listener.Close()
+	}
+	s.wg.Wait()
+}
+
+// GetCommands returns all commands received by the server
+func (s *SSHServer) GetCommands() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string{}, s.commands...)
+}
+
+func (s *SSHServer) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	serverConn, channels, requests, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		return
+	}
+	defer serverConn.Close()
+
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go s.handleSession(channel, channelRequests)
+	}
+}
+
+func (s *SSHServer) handleSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	signals := make(chan string, 10)
+	defer channel.Close()
+
+	for request := range requests {
+		switch request.Type {
+		case "exec":
+			var payload struct{ Command string }
+			if err := ssh.Unmarshal(request.Payload, &payload); err != nil {
+				_ = request.Reply(false, nil)
+				continue
+			}
+			_ = request.Reply(true, nil)
+
+			s.lock.Lock()
+			s.commands = append(s.commands, payload.Command)
+			s.lock.Unlock()
+
+			go func() {
+				status := s.options.Handler(payload.Command, channel, channel.Stderr(), signals)
+				_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+				_ = channel.Close()
+			}()
+		case "subsystem":
+			var payload struct{ Name string }
+			if err := ssh.Unmarshal(request.Payload, &payload); err != nil || payload.Name != "sftp" {
+				_ = request.Reply(false, nil)
+				continue
+			}
+			_ = request.Reply(true, nil)
+
+			go func() {
+				server, err := sftp.NewServer(channel)
+				if err == nil {
+					_ = server.Serve()
+					_ = server.Close()
+				}
+				_ = channel.Close()
+			}()
+		case "signal":
+			var payload struct{ Signal string }
+			if err := ssh.Unmarshal(request.Payload, &payload); err == nil {
+				signals <- payload.Signal
+			}
+		default:
+			if request.WantReply {
+				_ = request.Reply(false, nil)
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package utilstest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+const (
+	winRMEnvelopeStart = `<s:Envelope xml:lang="en-US" xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd" xmlns:rsp="http://schemas.microsoft.com/wbem/wsman/1/windows/shell"><s:Header></s:Header><s:Body>`
+	winRMEnvelopeEnd   = `</s:Body></s:Envelope>`
+	winRMShellID       = "67A74734-DD32-4F10-89DE-49A060483810"
+	winRMCommandID     = "1A6DEE6B-EC68-4DD6-87E9-030C0048ECC4"
+	winRMCommandState  = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/CommandState/"
+)
+
+var encodedCommandRegex = regexp.MustCompile(`-EncodedCommand ([A-Za-z0-9+/=]+)`)
+
+// WinRMCommandHandler executes a PowerShell script received by WinRMServer and returns its exit code
+type WinRMCommandHandler func(script string, stdout, stderr io.Writer, terminated <-chan struct{}) int
+
+type WinRMServerOptions struct {
+	User     string
+	Password string
+	Handler  WinRMCommandHandler
+}
+
+// WinRMServer is an in-process stand-in of a guest WinRM service supporting a single command at a time
+type WinRMServer struct {
+	options WinRMServerOptions
+	server  *httptest.Server
+
+	lock       sync.Mutex
+	scripts    []string
+	stdout     bytes.Buffer
+	stderr     bytes.Buffer
+	exitCode   *int
+	terminated chan struct{}
+}
+
+func NewWinRMServer(options WinRMServerOptions) *WinRMServer {
+	return &WinRMServer{options: options}
+}
+
+// Start listens on a random local port and returns it
+func (s *WinRMServer) Start() int {
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	_, port, err := net.SplitHostPort(strings.TrimPrefix(s.server.URL, "http://"))
+	gomega.Expect(err).Should(gomega.Succeed())
+
+	result, err := strconv.Atoi(port)
+	gomega.Expect(err).Should(gomega.Succeed())
+	return result
+}
+
+func (s *WinRMServer) Close() {
+	if s.server != nil {
+		s.server.Close()
+	}
+}
+
+// GetScripts returns all decoded scripts received by the server
+func (s *WinRMServer) GetScripts() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string{}, s.scripts...)
+}
+
+func (s *WinRMServer) handle(w http.ResponseWriter, r *http.Request) {
+	if user, password, ok := r.BasicAuth(); !ok || user != s.options.User || password != s.options.Password {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	request := string(body)
+
+	w.Header().Set("Content-Type", "application/soap+xml")
+
+	switch {
+	case strings.Contains(request, "transfer/Create"):
+		fmt.Fprintf(w, `%v<w:SelectorSet><w:Selector Name="ShellId">%v</w:Selector></w:SelectorSet>%v`, winRMEnvelopeStart, winRMShellID, winRMEnvelopeEnd)
+	case strings.Contains(request, "shell/Command"):
+		s.startCommand(request)
+		fmt.Fprintf(w, `%v<rsp:CommandResponse><rsp:CommandId>%v</rsp:CommandId></rsp:CommandResponse>%v`, winRMEnvelopeStart, winRMCommandID, winRMEnvelopeEnd)
+	case strings.Contains(request, "shell/Receive"):
+		fmt.Fprint(w, s.receive())
+	case strings.Contains(request, "shell/Signal"):
+		s.lock.Lock()
+		select {
+		case <-s.terminated:
+		default:
+			close(s.terminated)
+		}
+		s.lock.Unlock()
+		fmt.Fprint(w, winRMEnvelopeStart+winRMEnvelopeEnd)
+	default:
+		fmt.Fprint(w, winRMEnvelopeStart+winRMEnvelopeEnd)
+	}
+}
+
+func (s *WinRMServer) startCommand(request string) {
+	script := ""
+	if match := encodedCommandRegex.FindStringSubmatch(request); match != nil {
+		decoded, _ := base64.StdEncoding.DecodeString(match[1])
+		// PowerShell encoded commands are in UTF-16LE
+		for idx := 0; idx < len(decoded); idx += 2 {
+			script += string(decoded[idx])
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.scripts = append(s.scripts, script)
+	s.stdout.Reset()
+	s.stderr.Reset()
+	s.exitCode = nil
+	s.terminated = make(chan struct{})
+
+	go func(terminated chan struct{}) {
+		var stdout, stderr bytes.Buffer
+		exitCode := s.options.Handler(script, &stdout, &stderr, terminated)
+
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.stdout.Write(stdout.Bytes())
+		s.stderr.Write(stderr.Bytes())
+		s.exitCode = &exitCode
+	}(s.terminated)
+}
+
+func (s *WinRMServer) receive() string {
+	// simulate long polling of the output
+	deadline := time.Now().Add(100 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		s.lock.Lock()
+		finished := s.exitCode != nil
+		s.lock.Unlock()
+		if finished {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	response := winRMEnvelopeStart + "<rsp:ReceiveResponse>"
+	for name, stream := range map[string]*bytes.Buffer{"stdout": &s.stdout, "stderr": &s.stderr} {
+		if stream.Len() > 0 {
+			response += fmt.Sprintf(`<rsp:Stream Name="%v" CommandId="%v">%v</rsp:Stream>`, name, winRMCommandID, base64.StdEncoding.EncodeToString(stream.Bytes()))
+			stream.Reset()
+		}
+	}
+	if s.exitCode != nil {
+		response += fmt.Sprintf(`<rsp:CommandState CommandId="%v" State="%vDone"><rsp:ExitCode>%v</rsp:ExitCode></rsp:CommandState>`, winRMCommandID, winRMCommandState, *s.exitCode)
+	} else {
+		response += fmt.Sprintf(`<rsp:CommandState CommandId="%v" State="%vRunning"></rsp:CommandState>`, winRMCommandID, winRMCommandState)
+	}
+	return response + "</rsp:ReceiveResponse>" + winRMEnvelopeEnd
+}
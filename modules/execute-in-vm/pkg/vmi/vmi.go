@@ -2,26 +2,73 @@ package vmi
 
 import (
 	"errors"
+	"fmt"
+	"net"
 
+	corev1 "k8s.io/api/core/v1"
 	v1 "kubevirt.io/api/core/v1"
 )
 
 func GetPodIPAddress(vmi *v1.VirtualMachineInstance) (string, error) {
-	podNetworkName := ""
-	for _, network := range vmi.Spec.Networks {
-		if network.Pod != nil {
-			podNetworkName = network.Name
-			break
+	return GetIPAddress(vmi, "", "")
+}
+
+// GetIPAddress returns an IP address of the interface connected to the network with networkName
+// or to the pod network if networkName is empty. An address of the ipFamily is preferred if specified.
+// Empty address is returned if the interface has no address yet.
+func GetIPAddress(vmi *v1.VirtualMachineInstance, networkName string, ipFamily corev1.IPFamily) (string, error) {
+	if networkName == "" {
+		for _, network := range vmi.Spec.Networks {
+			if network.Pod != nil {
+				networkName = network.Name
+				break
+			}
 		}
-	}
-	if podNetworkName == "" {
-		return "", errors.New("pod network not found")
+		if networkName == "" {
+			return "", errors.New("pod network not found")
+		}
+	} else if !hasNetwork(vmi, networkName) {
+		return "", fmt.Errorf("network %v not found", networkName)
 	}
 
 	for _, statusInterface := range vmi.Status.Interfaces {
-		if statusInterface.Name == podNetworkName {
-			return statusInterface.IP, nil
+		if statusInterface.Name == networkName {
+			ips := statusInterface.IPs
+			if len(ips) == 0 && statusInterface.IP != "" {
+				ips = []string{statusInterface.IP}
+			}
+			return SelectIPAddress(ips, ipFamily), nil
 		}
 	}
 	return "", nil
 }
+
+// SelectIPAddress returns the first IP address of the ipFamily or the first IP address if ipFamily is empty
+func SelectIPAddress(ips []string, ipFamily corev1.IPFamily) string {
+	for _, ip := range ips {
+		if ipFamily == "" || GetIPFamily(ip) == ipFamily {
+			return ip
+		}
+	}
+	return ""
+}
+
+func GetIPFamily(ip string) corev1.IPFamily {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return ""
+	}
+	if parsedIP.To4() != nil {
+		return corev1.IPv4Protocol
+	}
+	return corev1.IPv6Protocol
+}
+
+func hasNetwork(vmi *v1.VirtualMachineInstance, networkName string) bool {
+	for _, network := range vmi.Spec.Networks {
+		if network.Name == networkName {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/sharedtest/testobjects"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	v1 "kubevirt.io/api/core/v1"
 )
 
@@ -43,4 +44,63 @@ var _ = Describe("VMI", func() {
 			Expect(ipAddress).Should(Equal(ip))
 		})
 	})
+
+	Describe("GetIPAddress", func() {
+		BeforeEach(func() {
+			vmi.Spec.Networks = append(vmi.Spec.Networks, v1.Network{
+				Name: "secondary",
+				NetworkSource: v1.NetworkSource{
+					Multus: &v1.MultusNetwork{NetworkName: "vlan-100"},
+				},
+			})
+			vmi.Status = v1.VirtualMachineInstanceStatus{
+				Interfaces: []v1.VirtualMachineInstanceNetworkInterface{
+					{
+						Name: vmi.Spec.Networks[0].Name,
+						IP:   "10.0.2.2",
+						IPs:  []string{"10.0.2.2", "fd10:0:2::2"},
+					},
+					{
+						Name:          "secondary",
+						InterfaceName: "eth1",
+						IP:            "fd00:100::5",
+						IPs:           []string{"fd00:100::5", "192.168.100.5"},
+					},
+				},
+			}
+		})
+
+		DescribeTable("selects IP address", func(networkName string, ipFamily corev1.IPFamily, expectedIPAddress string) {
+			ipAddress, err := GetIPAddress(vmi, networkName, ipFamily)
+			Expect(err).Should(Succeed())
+			Expect(ipAddress).Should(Equal(expectedIPAddress))
+		},
+			Entry("pod network", "", corev1.IPFamily(""), "10.0.2.2"),
+			Entry("pod network IPv6", "", corev1.IPv6Protocol, "fd10:0:2::2"),
+			Entry("pod network by name", "default", corev1.IPv4Protocol, "10.0.2.2"),
+			Entry("secondary network", "secondary", corev1.IPFamily(""), "fd00:100::5"),
+			Entry("secondary network IPv4", "secondary", corev1.IPv4Protocol, "192.168.100.5"),
+		)
+
+		It("returns empty when IP family is not available", func() {
+			vmi.Status.Interfaces[0].IPs = nil
+			ipAddress, err := GetIPAddress(vmi, "", corev1.IPv6Protocol)
+			Expect(err).Should(Succeed())
+			Expect(ipAddress).Should(BeEmpty())
+		})
+
+		It("returns empty when interface is not reported yet", func() {
+			vmi.Status.Interfaces = vmi.Status.Interfaces[:1]
+			ipAddress, err := GetIPAddress(vmi, "secondary", "")
+			Expect(err).Should(Succeed())
+			Expect(ipAddress).Should(BeEmpty())
+		})
+
+		It("fails on unknown network", func() {
+			ipAddress, err := GetIPAddress(vmi, "unknown", "")
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).To(Equal("network unknown not found"))
+			Expect(ipAddress).Should(BeEmpty())
+		})
+	})
 })
@@ -5,12 +5,13 @@ import (
 )
 
 const (
-	// ConnectionSecretTypeKey supports the following values: ssh
+	// ConnectionSecretTypeKey supports the following values: ssh, winrm, guest-agent
 	ConnectionSecretTypeKey = "type"
 )
 
 type sshConnectionSecretKeys struct {
 	User                         string
+	Password                     string
 	PrivateKey                   string
 	PrivateKeyAlternativeFormat  string
 	HostPublicKey                string
@@ -20,9 +21,36 @@ type sshConnectionSecretKeys struct {
 
 var SSHConnectionSecretKeys = sshConnectionSecretKeys{
 	User:                         "user",
+	Password:                     "password",
 	PrivateKey:                   corev1.SSHAuthPrivateKey,
 	PrivateKeyAlternativeFormat:  "ssh-private-key",
 	HostPublicKey:                "host-public-key",
 	DisableStrictHostKeyChecking: "disable-strict-host-key-checking",
 	AdditionalSSHOptions:         "additional-ssh-options",
 }
+
+type winRMConnectionSecretKeys struct {
+	User                         string
+	Password                     string
+	Port                         string
+	UseHTTPS                     string
+	DisableCertificateValidation string
+	CACert                       string
+}
+
+var WinRMConnectionSecretKeys = winRMConnectionSecretKeys{
+	User:                         "user",
+	Password:                     "password",
+	Port:                         "port",
+	UseHTTPS:                     "use-https",
+	DisableCertificateValidation: "disable-certificate-validation",
+	CACert:                       "ca-cert",
+}
+
+type guestAgentConnectionSecretKeys struct {
+	Shell string
+}
+
+var GuestAgentConnectionSecretKeys = guestAgentConnectionSecretKeys{
+	Shell: "shell",
+}
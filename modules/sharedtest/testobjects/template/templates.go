@@ -93,6 +93,17 @@ func (t *TestTemplate) WithVolume(volume kubevirtv1.Volume) *TestTemplate {
 	return t
 }
 
+func (t *TestTemplate) WithDiskBus(bus kubevirtv1.DiskBus) *TestTemplate {
+	t.modifyVM(func(vm *kubevirtv1.VirtualMachine) {
+		for idx := range vm.Spec.Template.Spec.Domain.Devices.Disks {
+			if disk := vm.Spec.Template.Spec.Domain.Devices.Disks[idx].Disk; disk != nil {
+				disk.Bus = bus
+			}
+		}
+	})
+	return t
+}
+
 func (t *TestTemplate) WithNonMatchingDisk() *TestTemplate {
 	t.modifyVM(func(vm *kubevirtv1.VirtualMachine) {
 		vm.Spec.Template.Spec.Domain.Devices.Disks[0].Name = "non-matching-name"
@@ -146,3 +157,16 @@ func (t *TestTemplate) WithVirtioDiskValidations() *TestTemplate {
 ]`
 	return t
 }
+
+func (t *TestTemplate) WithUnsatisfiableMemoryValidations() *TestTemplate {
+	t.Data.Annotations[validationsAnnotation] = `[
+  {
+    "name": "minimal-required-memory",
+    "path": "jsonpath::.spec.domain.resources.requests.memory",
+    "rule": "integer",
+    "message": "This VM requires more memory.",
+    "min": 1099511627776
+  }
+]`
+	return t
+}
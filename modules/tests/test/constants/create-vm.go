@@ -11,6 +11,8 @@ type createVMParams struct {
 	OwnDataVolumes            string
 	PersistentVolumeClaims    string
 	OwnPersistentVolumeClaims string
+	DryRun                    string
+	DataVolumeSources         string
 }
 
 type createVMFromManifestParams struct {
@@ -32,6 +34,8 @@ var CreateVMParams = createVMParams{
 	OwnDataVolumes:            "ownDataVolumes",
 	PersistentVolumeClaims:    "persistentVolumeClaims",
 	OwnPersistentVolumeClaims: "ownPersistentVolumeClaims",
+	DryRun:                    "dryRun",
+	DataVolumeSources:         "dataVolumeSources",
 }
 
 var CreateVMFromManifestParams = createVMFromManifestParams{
@@ -51,11 +55,13 @@ var CreateVMFromTemplateParams = createVMFromTemplateParams{
 type createVMResults struct {
 	Name      string
 	Namespace string
+	Manifest  string
 }
 
 var CreateVMResults = createVMResults{
 	Name:      "name",
 	Namespace: "namespace",
+	Manifest:  "manifest",
 }
 
 type CreateVMMode string
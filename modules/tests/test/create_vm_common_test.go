@@ -93,7 +93,8 @@ var _ = Describe("Create VM", func() {
 							testTemplate := template.NewCirrosServerTinyTemplate()
 							switch idx % 4 { // try different disk buses for each test
 							case 0:
-								testTemplate.WithSataDiskValidations()
+								// the disks of the template have to satisfy the validations too
+								testTemplate.WithDiskBus(kubevirtv1.DiskBusSATA).WithSataDiskValidations()
 								expectedDiskBus = "sata"
 							case 1:
 								testTemplate.WithSCSIDiskValidations()
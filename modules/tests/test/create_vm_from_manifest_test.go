@@ -9,6 +9,7 @@ import (
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/tests/test/vm"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 )
@@ -406,4 +407,91 @@ var _ = Describe("Create VM from manifest", func() {
 			}, kubevirtv1.RunStrategyRerunOnFailure),
 		)
 	})
+
+	It("VM is created with DataVolumes from sources", func() {
+		config := &testconfigs.CreateVMTestConfig{
+			TaskRunTestConfig: testconfigs.TaskRunTestConfig{
+				ServiceAccount: CreateVMFromManifestServiceAccountName,
+				ExpectedLogs:   ExpectedSuccessfulVMCreation,
+				Timeout:        Timeouts.SmallDVCreation,
+			},
+			TaskData: testconfigs.CreateVMTaskData{
+				VM:                testobjects.NewTestAlpineVM("vm-from-manifest-dv-sources").Build(),
+				DataVolumeSources: "- name: blankdisk\n  source:\n    blank: {}\n  size: 100Mi",
+			},
+		}
+		f.TestSetup(config)
+
+		expectedVMStub := config.TaskData.GetExpectedVMStubMeta()
+		f.ManageVMs(expectedVMStub)
+
+		runner.NewTaskRunRunner(f, config.GetTaskRun()).
+			CreateTaskRun().
+			ExpectSuccess().
+			ExpectLogs(config.GetAllExpectedLogs()...).
+			ExpectResults(map[string]string{
+				CreateVMResults.Name:      expectedVMStub.Name,
+				CreateVMResults.Namespace: expectedVMStub.Namespace,
+			})
+
+		vm, err := vm.WaitForVM(f.KubevirtClient, f.CdiClient, expectedVMStub.Namespace, expectedVMStub.Name,
+			"", config.GetTaskRunTimeout(), false)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		dvName := expectedVMStub.Name + "-blankdisk"
+		Expect(vm.Spec.DataVolumeTemplates).To(HaveLen(1))
+		Expect(vm.Spec.DataVolumeTemplates[0].Name).To(Equal(dvName))
+		Expect(vm.Spec.Template.Spec.Volumes).To(ContainElement(kubevirtv1.Volume{
+			Name: "blankdisk",
+			VolumeSource: kubevirtv1.VolumeSource{
+				DataVolume: &kubevirtv1.DataVolumeSource{Name: dvName},
+			},
+		}))
+	})
+
+	DescribeTable("VM is not created in dry run", func(config *testconfigs.CreateVMTestConfig) {
+		f.TestSetup(config)
+
+		expectedVMStub := config.TaskData.GetExpectedVMStubMeta()
+		f.ManageVMs(expectedVMStub) // in case it is created
+
+		results := runner.NewTaskRunRunner(f, config.GetTaskRun()).
+			CreateTaskRun().
+			ExpectSuccess().
+			ExpectLogs(config.GetAllExpectedLogs()...).
+			ExpectResultsWithLen(map[string]string{
+				CreateVMResults.Name:      expectedVMStub.Name,
+				CreateVMResults.Namespace: expectedVMStub.Namespace,
+			}, 3).
+			GetResults()
+
+		Expect(results[CreateVMResults.Manifest]).To(ContainSubstring(ExpectedSuccessfulVMCreation))
+		Expect(results[CreateVMResults.Manifest]).To(ContainSubstring("name: " + expectedVMStub.Name))
+
+		_, err := f.KubevirtClient.VirtualMachine(expectedVMStub.Namespace).Get(expectedVMStub.Name, &v1.GetOptions{})
+		Expect(errors.IsNotFound(err)).To(BeTrue(), "vm should not be created")
+	},
+		Entry("with client DryRun", &testconfigs.CreateVMTestConfig{
+			TaskRunTestConfig: testconfigs.TaskRunTestConfig{
+				ServiceAccount: CreateVMFromManifestServiceAccountName,
+				ExpectedLogs:   ExpectedSuccessfulVMCreation,
+			},
+			TaskData: testconfigs.CreateVMTaskData{
+				VM:      testobjects.NewTestAlpineVM("vm-from-manifest-dry-run").Build(),
+				StartVM: "true",
+				DryRun:  "client",
+			},
+		}),
+		Entry("with server DryRun", &testconfigs.CreateVMTestConfig{
+			TaskRunTestConfig: testconfigs.TaskRunTestConfig{
+				ServiceAccount: CreateVMFromManifestServiceAccountName,
+				ExpectedLogs:   ExpectedSuccessfulVMCreation,
+			},
+			TaskData: testconfigs.CreateVMTaskData{
+				VM:      testobjects.NewTestAlpineVM("vm-from-manifest-dry-run").Build(),
+				StartVM: "true",
+				DryRun:  "server",
+			},
+		}),
+	)
 })
@@ -67,6 +67,30 @@ var _ = Describe("Create VM from template", func() {
 				},
 			},
 		}),
+		Entry("template validations not satisfied", &testconfigs.CreateVMTestConfig{
+			TaskRunTestConfig: testconfigs.TaskRunTestConfig{
+				ServiceAccount: CreateVMFromTemplateServiceAccountName,
+				ExpectedLogs:   "minimal-required-memory: This VM requires more memory.: value 64Mi of .spec.domain.resources.requests.memory is lower than 1099511627776",
+			},
+			TaskData: testconfigs.CreateVMTaskData{
+				Template: testtemplate.NewCirrosServerTinyTemplate().WithUnsatisfiableMemoryValidations().Build(),
+				TemplateParams: []string{
+					testtemplate.TemplateParam(testtemplate.NameParam, E2ETestsRandomName("invalid-template-validations")),
+				},
+			},
+		}),
+		Entry("template disks violate template validations", &testconfigs.CreateVMTestConfig{
+			TaskRunTestConfig: testconfigs.TaskRunTestConfig{
+				ServiceAccount: CreateVMFromTemplateServiceAccountName,
+				ExpectedLogs:   `disk-bus: : value "virtio" of .spec.domain.devices.disks[*].disk.bus is not one of sata`,
+			},
+			TaskData: testconfigs.CreateVMTaskData{
+				Template: testtemplate.NewCirrosServerTinyTemplate().WithSataDiskValidations().Build(),
+				TemplateParams: []string{
+					testtemplate.TemplateParam(testtemplate.NameParam, E2ETestsRandomName("invalid-template-disks")),
+				},
+			},
+		}),
 		Entry("non existent template", &testconfigs.CreateVMTestConfig{
 			TaskRunTestConfig: testconfigs.TaskRunTestConfig{
 				ServiceAccount: CreateVMFromTemplateServiceAccountName,
@@ -34,6 +34,7 @@ type CreateVMTaskData struct {
 	UseDefaultVMNamespacesInTaskParams       bool
 	StartVM                                  string
 	RunStrategy                              string
+	DryRun                                   string
 	ExpectedAdditionalDiskBus                string
 
 	// Params
@@ -51,6 +52,7 @@ type CreateVMTaskData struct {
 	OwnDataVolumes            []string
 	PersistentVolumeClaims    []string
 	OwnPersistentVolumeClaims []string
+	DataVolumeSources         string
 }
 
 func (c *CreateVMTaskData) GetTemplateParam(key string) string {
@@ -346,6 +348,26 @@ func (c *CreateVMTestConfig) GetTaskRun() *v1beta1.TaskRun {
 		})
 	}
 
+	if c.TaskData.DataVolumeSources != "" {
+		params = append(params, v1beta1.Param{
+			Name: CreateVMParams.DataVolumeSources,
+			Value: v1beta1.ArrayOrString{
+				Type:      v1beta1.ParamTypeString,
+				StringVal: c.TaskData.DataVolumeSources,
+			},
+		})
+	}
+
+	if c.TaskData.DryRun != "" {
+		params = append(params, v1beta1.Param{
+			Name: CreateVMParams.DryRun,
+			Value: v1beta1.ArrayOrString{
+				Type:      v1beta1.ParamTypeString,
+				StringVal: c.TaskData.DryRun,
+			},
+		})
+	}
+
 	var vmNamespace string
 	if !c.TaskData.UseDefaultVMNamespacesInTaskParams {
 		vmNamespace = c.TaskData.VMNamespace
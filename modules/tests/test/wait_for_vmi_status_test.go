@@ -40,7 +40,7 @@ var _ = Describe("Wait for VMI Status", func() {
 		// negative validation cases
 		Entry("no vmi", &testconfigs.WaitForVMIStatusTestConfig{
 			TaskRunTestConfig: testconfigs.TaskRunTestConfig{
-				ExpectedLogs: "vmi-name should not be empty",
+				ExpectedLogs: "vmi-name or vmi-selector should not be empty",
 			},
 			TaskData: testconfigs.WaitForVMIStatusTaskData{},
 		}),
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+
 	goarg "github.com/alexflint/go-arg"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/exit"
 	. "github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/constants"
@@ -8,6 +10,7 @@ import (
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/utils/parse"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/watch"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"os"
 )
 
@@ -30,7 +33,21 @@ func main() {
 		exit.ExitOrDieFromError(WatchFacadeInitFailed, err)
 	}
 
-	success := watchFacade.WaitForVMIConditions()
+	success, err := watchFacade.WaitForConditions()
+
+	if resultsErr := watchFacade.RecordResults(); resultsErr != nil {
+		log.Logger().Warn("could not record results", zap.Error(resultsErr))
+	}
+
+	if err == wait.ErrWaitTimeout {
+		exit.ExitOrDieFromError(WaitTimeout, exit.Exit{
+			Code: WaitTimeout,
+			Msg:  fmt.Sprintf("timed out after %v waiting for the conditions", cliOptions.GetTimeout()),
+			Soft: true,
+		})
+	} else if err != nil {
+		exit.ExitOrDieFromError(WatchFailed, err)
+	}
 
 	if !success {
 		os.Exit(FailureConditionFulfilled)
@@ -1,8 +1,48 @@
 package constants
 
+import "time"
+
+// DefaultReevaluationInterval is the default interval of evaluating the conditions of unchanged objects again
+const DefaultReevaluationInterval = 30 * time.Second
+
 // Exit codes
 const (
 	FailureConditionFulfilled = 2
+	WaitTimeout               = 3
 	InvalidArguments          = -1 // same as go-arg invalid args exit
 	WatchFacadeInitFailed     = -2
+	WatchFailed               = -3
+)
+
+// Result names
+const (
+	PhaseResultName      = "phase"
+	ConditionsResultName = "conditions"
+	StatesResultName     = "states"
+	HistoryResultName    = "history"
+)
+
+// AllSuccessQuorum requires all selected objects to fulfill the success condition
+const AllSuccessQuorum = "all"
+
+// FailurePolicy decides when the failure condition fulfilled by the selected objects fails the wait
+type FailurePolicy string
+
+const (
+	// FailFastFailurePolicy fails once any object fulfills the failure condition
+	FailFastFailurePolicy FailurePolicy = "fail-fast"
+	// QuorumFailurePolicy fails once the success quorum can not be reached by the objects which did not fulfill the failure condition
+	QuorumFailurePolicy FailurePolicy = "quorum"
+)
+
+// DeletionPolicy decides what the deletion of a watched object means
+type DeletionPolicy string
+
+const (
+	// WaitDeletionPolicy waits for the next object with the same name
+	WaitDeletionPolicy DeletionPolicy = "wait"
+	// FailDeletionPolicy treats the deleted object as if it fulfilled the failure condition
+	FailDeletionPolicy DeletionPolicy = "fail"
+	// SucceedDeletionPolicy treats the deleted object as if it fulfilled the success condition
+	SucceedDeletionPolicy DeletionPolicy = "succeed"
 )
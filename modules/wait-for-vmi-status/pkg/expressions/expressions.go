@@ -0,0 +1,87 @@
+package expressions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zerrors"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Names of the variables holding the watched object in expressions. The vmi variable is kept for
+// the expressions written before other resources than VMIs could be watched.
+const (
+	ObjectVariableName = "object"
+	VMIVariableName    = "vmi"
+)
+
+// NowVariableName is the name of the variable holding the time of the evaluation, so expressions can compare timestamps
+const NowVariableName = "now"
+
+// Expression is a compiled CEL expression evaluated against the watched object
+type Expression struct {
+	source  string
+	program cel.Program
+}
+
+// Compile compiles a CEL expression which has to evaluate to bool. Returns nil for an empty expression.
+func Compile(source string) (*Expression, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, nil
+	}
+
+	env, err := cel.NewEnv(
+		cel.Variable(ObjectVariableName, cel.DynType),
+		cel.Variable(VMIVariableName, cel.DynType),
+		cel.Variable(NowVariableName, cel.TimestampType),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(source)
+	if issues != nil && issues.Err() != nil {
+		return nil, zerrors.NewMissingRequiredError("could not compile expression %v: %v", source, issues.Err().Error())
+	}
+
+	if outputType := ast.OutputType(); !outputType.IsAssignableType(cel.BoolType) {
+		return nil, zerrors.NewMissingRequiredError("invalid expression %v: should evaluate to bool, not %v", source, outputType)
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, zerrors.NewMissingRequiredError("invalid expression %v: %v", source, err.Error())
+	}
+
+	return &Expression{source: source, program: program}, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Matches evaluates the expression against obj. Fails if the expression cannot be evaluated,
+// e.g. when it references a field which is not set.
+func (e *Expression) Matches(obj interface{}) (bool, error) {
+	unstructuredObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return false, err
+	}
+
+	result, _, err := e.program.Eval(map[string]interface{}{
+		ObjectVariableName: unstructuredObj,
+		VMIVariableName:    unstructuredObj,
+		NowVariableName:    time.Now(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	matched, ok := result.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %v evaluated to %v instead of bool", e.source, result.Type().TypeName())
+	}
+	return matched, nil
+}
@@ -0,0 +1,13 @@
+package expressions_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestExpressions(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Expressions Suite")
+}
@@ -0,0 +1,72 @@
+package expressions_test
+
+import (
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/expressions"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+var _ = Describe("Expressions", func() {
+	vmi := &kubevirtv1.VirtualMachineInstance{
+		ObjectMeta: v1.ObjectMeta{Name: "fedora", Namespace: "default", CreationTimestamp: v1.Date(2022, 10, 5, 12, 0, 0, 0, time.UTC)},
+		Status: kubevirtv1.VirtualMachineInstanceStatus{
+			Phase: kubevirtv1.Running,
+			Conditions: []kubevirtv1.VirtualMachineInstanceCondition{
+				{Type: kubevirtv1.VirtualMachineInstanceReady, Status: corev1.ConditionTrue},
+				{Type: kubevirtv1.VirtualMachineInstanceAgentConnected, Status: corev1.ConditionFalse},
+			},
+			Interfaces: []kubevirtv1.VirtualMachineInstanceNetworkInterface{
+				{Name: "default", IP: "10.0.0.5"},
+			},
+		},
+	}
+
+	It("returns nil for an empty expression", func() {
+		Expect(expressions.Compile("  ")).To(BeNil())
+	})
+
+	DescribeTable("fails to compile invalid expressions", func(expression string, expectedErrMessage string) {
+		_, err := expressions.Compile(expression)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(expectedErrMessage))
+	},
+		Entry("syntax error", "vmi.status.phase ==", "could not compile expression"),
+		Entry("undeclared variable", "vm.status.phase == 'Running'", "undeclared reference to 'vm'"),
+		Entry("non bool result", "vmi.status.phase + 'x'", "should evaluate to bool"),
+		Entry("number result", "size(vmi.status.interfaces)", "should evaluate to bool, not int"),
+	)
+
+	DescribeTable("evaluates expressions against a VMI", func(expression string, expectedMatch bool) {
+		compiledExpression, err := expressions.Compile(expression)
+		Expect(err).Should(Succeed())
+
+		matched, err := compiledExpression.Matches(vmi)
+		Expect(err).Should(Succeed())
+		Expect(matched).To(Equal(expectedMatch))
+	},
+		Entry("phase", "vmi.status.phase == 'Running'", true),
+		Entry("phase in list", "vmi.status.phase in ['Failed', 'Unknown']", false),
+		Entry("ready condition", "vmi.status.conditions.exists(c, c.type == 'Ready' && c.status == 'True')", true),
+		Entry("agent connected condition", "vmi.status.conditions.exists(c, c.type == 'AgentConnected' && c.status == 'True')", false),
+		Entry("numeric comparison", "size(vmi.status.interfaces) >= 1", true),
+		Entry("missing field with has", "has(vmi.status.nodeName) && vmi.status.nodeName == 'node01'", false),
+		Entry("name", "vmi.metadata.name.startsWith('fed')", true),
+		Entry("now", "now > timestamp('2022-10-05T12:00:00Z')", true),
+		Entry("older than", "now - timestamp(vmi.metadata.creationTimestamp) > duration('1h')", true),
+		Entry("younger than", "now - timestamp(vmi.metadata.creationTimestamp) < duration('1h')", false),
+	)
+
+	It("fails to evaluate an expression referencing a missing field", func() {
+		compiledExpression, err := expressions.Compile("vmi.status.nodeName == 'node01'")
+		Expect(err).Should(Succeed())
+
+		matched, err := compiledExpression.Matches(vmi)
+		Expect(err).Should(HaveOccurred())
+		Expect(matched).To(BeFalse())
+	})
+})
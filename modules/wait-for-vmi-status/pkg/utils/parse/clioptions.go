@@ -1,23 +1,44 @@
 package parse
 
 import (
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/expressions"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/requirements"
 	"go.uber.org/zap/zapcore"
 	"k8s.io/apimachinery/pkg/labels"
+	"strconv"
+	"time"
 )
 
 const (
-	vmiNameOptionName          = "vmi-name"
-	vmiNamespaceOptionName     = "vmi-namespace"
-	successConditionOptionName = "success-condition"
-	failureConditionOptionName = "failure-condition"
+	vmiNameOptionName              = "vmi-name"
+	vmiNamespaceOptionName         = "vmi-namespace"
+	vmiSelectorOptionName          = "vmi-selector"
+	successQuorumOptionName        = "success-quorum"
+	failurePolicyOptionName        = "failure-policy"
+	deletionPolicyOptionName       = "deletion-policy"
+	successConditionOptionName     = "success-condition"
+	failureConditionOptionName     = "failure-condition"
+	successExpressionOptionName    = "success-expression"
+	failureExpressionOptionName    = "failure-expression"
+	timeoutOptionName              = "timeout"
+	reevaluationIntervalOptionName = "reevaluation-interval"
 )
 
 type CLIOptions struct {
-	VirtualMachineInstanceName      string `arg:"--vmi-name,env:VMI_NAME" placeholder:"NAME" help:"Name of a VMI to wait for."`
-	VirtualMachineInstanceNamespace string `arg:"--vmi-namespace,env:VMI_NAMESPACE" placeholder:"NAME" help:"Namespace of a VMI to wait for."`
+	VirtualMachineInstanceName      string `arg:"--vmi-name,env:VMI_NAME" placeholder:"NAME" help:"Name of a VMI, or of an object of the resource-kind, to wait for."`
+	VirtualMachineInstanceNamespace string `arg:"--vmi-namespace,env:VMI_NAMESPACE" placeholder:"NAME" help:"Namespace of a VMI, or of an object of the resource-kind, to wait for. Ignored for cluster scoped resources."`
+	VirtualMachineInstanceSelector  string `arg:"--vmi-selector,env:VMI_SELECTOR" placeholder:"SELECTOR" help:"A label selector of VMIs, or of objects of the resource-kind, to wait for. Can be used instead of vmi-name."`
+	ResourceKind                    string `arg:"--resource-kind,env:RESOURCE_KIND" placeholder:"KIND[.VERSION][.GROUP]" help:"Kind, resource or short name of an object to wait for instead of a VMI. Eg. \"VirtualMachine\", \"datavolumes.cdi.kubevirt.io\" or \"pvc\"."`
 	SuccessCondition                string `arg:"--success-condition,env:SUCCESS_CONDITION" placeholder:"CONDITION" help:" A label selector expression to decide if the VirtualMachineInstance (VMI) is in a success state. Eg. \"status.phase == Succeeded\". It is evaluated on each VMI update and will result in this task succeeding if true."`
 	FailureCondition                string `arg:"--failure-condition,env:FAILURE_CONDITION" placeholder:"CONDITION" help:"A label selector expression to decide if the VirtualMachineInstance (VMI) is in a failed state. Eg. \"status.phase in (Failed, Unknown)\". It is evaluated on each VMI update and will result in this task failing if true."`
+	SuccessExpression               string `arg:"--success-expression,env:SUCCESS_EXPRESSION" placeholder:"EXPRESSION" help:"A CEL expression to decide if the VirtualMachineInstance (VMI) is in a success state. The VMI is available as the vmi variable. Eg. \"vmi.status.conditions.exists(c, c.type == 'Ready' && c.status == 'True')\". It is evaluated on each VMI update and will result in this task succeeding if true."`
+	FailureExpression               string `arg:"--failure-expression,env:FAILURE_EXPRESSION" placeholder:"EXPRESSION" help:"A CEL expression to decide if the VirtualMachineInstance (VMI) is in a failed state. The VMI is available as the vmi variable. Eg. \"vmi.status.phase in ['Failed', 'Unknown']\". It is evaluated on each VMI update and will result in this task failing if true."`
+	SuccessQuorum                   string `arg:"--success-quorum,env:SUCCESS_QUORUM" placeholder:"all|N" help:"Number of the objects selected by vmi-selector which have to fulfill the success condition. Defaults to all."`
+	FailurePolicy                   string `arg:"--failure-policy,env:FAILURE_POLICY" placeholder:"fail-fast|quorum" help:"Decides when the objects selected by vmi-selector fail the wait. fail-fast fails once any object fulfills the failure condition, quorum once the success quorum can not be reached anymore. Defaults to fail-fast."`
+	DeletionPolicy                  string `arg:"--deletion-policy,env:DELETION_POLICY" placeholder:"wait|fail|succeed" help:"Decides what the deletion of a watched object means. wait waits for the next object with the same name, fail and succeed treat the object as if it fulfilled the failure or success condition. Defaults to wait."`
+	Timeout                         string `arg:"--timeout,env:TIMEOUT" placeholder:"DURATION" help:"Timeout for the wait. The task fails with exit code 3 once the timeout expires. Waits forever when empty. Should be in a 3h2m1s format."`
+	ReevaluationInterval            string `arg:"--reevaluation-interval,env:REEVALUATION_INTERVAL" placeholder:"DURATION" help:"Interval of evaluating the conditions of unchanged objects again, e.g. for expressions comparing timestamps with now. Zero disables it. Defaults to 30s. Should be in a 3h2m1s format."`
 	Debug                           bool   `arg:"--debug" help:"Sets DEBUG log level"`
 }
 
@@ -36,6 +57,14 @@ func (c *CLIOptions) GetVirtualMachineInstanceNamespace() string {
 	return c.VirtualMachineInstanceNamespace
 }
 
+func (c *CLIOptions) GetVirtualMachineInstanceSelector() string {
+	return c.VirtualMachineInstanceSelector
+}
+
+func (c *CLIOptions) GetResourceKind() string {
+	return c.ResourceKind
+}
+
 func (c *CLIOptions) GetSuccessCondition() string {
 	return c.SuccessCondition
 }
@@ -44,6 +73,74 @@ func (c *CLIOptions) GetFailureCondition() string {
 	return c.FailureCondition
 }
 
+func (c *CLIOptions) GetSuccessExpression() string {
+	return c.SuccessExpression
+}
+
+func (c *CLIOptions) GetFailureExpression() string {
+	return c.FailureExpression
+}
+
+func (c *CLIOptions) GetCompiledSuccessExpression() *expressions.Expression {
+	expression, err := expressions.Compile(c.SuccessExpression)
+	if err != nil {
+		panic("Init should be called first to validate the SuccessExpression")
+	}
+	return expression
+}
+
+func (c *CLIOptions) GetCompiledFailureExpression() *expressions.Expression {
+	expression, err := expressions.Compile(c.FailureExpression)
+	if err != nil {
+		panic("Init should be called first to validate the FailureExpression")
+	}
+	return expression
+}
+
+// GetSuccessQuorum returns the number of objects which have to fulfill the success condition. Zero means all objects.
+func (c *CLIOptions) GetSuccessQuorum() int {
+	if c.SuccessQuorum != "" && c.SuccessQuorum != constants.AllSuccessQuorum {
+		if successQuorum, err := strconv.Atoi(c.SuccessQuorum); err == nil {
+			return successQuorum
+		}
+	}
+	return 0
+}
+
+func (c *CLIOptions) GetFailurePolicy() constants.FailurePolicy {
+	if c.FailurePolicy == "" {
+		return constants.FailFastFailurePolicy
+	}
+	return constants.FailurePolicy(c.FailurePolicy)
+}
+
+func (c *CLIOptions) GetDeletionPolicy() constants.DeletionPolicy {
+	if c.DeletionPolicy == "" {
+		return constants.WaitDeletionPolicy
+	}
+	return constants.DeletionPolicy(c.DeletionPolicy)
+}
+
+// GetTimeout returns the timeout of the wait. Zero means no timeout.
+func (c *CLIOptions) GetTimeout() time.Duration {
+	if c.Timeout != "" {
+		if timeout, err := time.ParseDuration(c.Timeout); err == nil {
+			return timeout
+		}
+	}
+	return 0
+}
+
+// GetReevaluationInterval returns the interval of evaluating the conditions of unchanged objects again. Zero means never.
+func (c *CLIOptions) GetReevaluationInterval() time.Duration {
+	if c.ReevaluationInterval != "" {
+		if reevaluationInterval, err := time.ParseDuration(c.ReevaluationInterval); err == nil {
+			return reevaluationInterval
+		}
+	}
+	return constants.DefaultReevaluationInterval
+}
+
 func (c *CLIOptions) GetSuccessRequirements() labels.Requirements {
 	reqs, err := requirements.GetLabelRequirement(c.SuccessCondition)
 	if err != nil {
@@ -71,9 +168,25 @@ func (c *CLIOptions) Init() error {
 		return err
 	}
 
+	if err := c.validateQuorum(); err != nil {
+		return err
+	}
+
+	if err := c.validateDeletionPolicy(); err != nil {
+		return err
+	}
+
 	if err := c.validateConditions(); err != nil {
 		return err
 	}
 
+	if err := c.validateExpressions(); err != nil {
+		return err
+	}
+
+	if err := c.validateDurations(); err != nil {
+		return err
+	}
+
 	return nil
 }
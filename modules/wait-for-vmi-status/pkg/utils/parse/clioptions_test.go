@@ -2,7 +2,9 @@ package parse_test
 
 import (
 	"reflect"
+	"time"
 
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/constants"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/utils/parse"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/utilstest"
 	. "github.com/onsi/ginkgo/v2"
@@ -20,7 +22,39 @@ var _ = Describe("CLIOptions", func() {
 	DescribeTable("Init return correct assertion errors", func(expectedErrMessage string, options *parse.CLIOptions) {
 		Expect(options.Init().Error()).To(ContainSubstring(expectedErrMessage))
 	},
-		Entry("empty vmi name", "vmi-name should not be empty", &parse.CLIOptions{}),
+		Entry("empty vmi name", "vmi-name or vmi-selector should not be empty", &parse.CLIOptions{}),
+		Entry("vmi name and selector", "only one of vmi-name|vmi-selector options is allowed", &parse.CLIOptions{
+			VirtualMachineInstanceName:     "test",
+			VirtualMachineInstanceSelector: "app=test",
+		}),
+		Entry("invalid vmi selector", "invalid vmi-selector value", &parse.CLIOptions{
+			VirtualMachineInstanceSelector: "app==(test",
+		}),
+		Entry("success quorum without selector", "success-quorum option is allowed only with vmi-selector option", &parse.CLIOptions{
+			VirtualMachineInstanceName:      "test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			SuccessQuorum:                   "2",
+		}),
+		Entry("failure policy without selector", "failure-policy option is allowed only with vmi-selector option", &parse.CLIOptions{
+			VirtualMachineInstanceName:      "test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			FailurePolicy:                   "quorum",
+		}),
+		Entry("invalid success quorum", "invalid success-quorum value some: should be all or a positive number", &parse.CLIOptions{
+			VirtualMachineInstanceSelector:  "app=test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			SuccessQuorum:                   "some",
+		}),
+		Entry("zero success quorum", "invalid success-quorum value 0: should be all or a positive number", &parse.CLIOptions{
+			VirtualMachineInstanceSelector:  "app=test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			SuccessQuorum:                   "0",
+		}),
+		Entry("invalid failure policy", "invalid option failure-policy any, only fail-fast|quorum is allowed", &parse.CLIOptions{
+			VirtualMachineInstanceSelector:  "app=test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			FailurePolicy:                   "any",
+		}),
 		Entry("invalid vmi name", "invalid vmi-name value: a lowercase RFC 1123 subdomain must consist of", &parse.CLIOptions{
 			VirtualMachineInstanceName: "invalid name",
 		}),
@@ -48,6 +82,48 @@ var _ = Describe("CLIOptions", func() {
 			VirtualMachineInstanceNamespace: defaultNS,
 			FailureCondition:                "test.....test",
 		}),
+		Entry("invalid success expression", "success-expression: could not compile expression", &parse.CLIOptions{
+			VirtualMachineInstanceName:      "test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			SuccessExpression:               "vmi.status.phase ==",
+		}),
+		Entry("non bool failure expression", "failure-expression: invalid expression size(vmi.status.conditions): should evaluate to bool, not int", &parse.CLIOptions{
+			VirtualMachineInstanceName:      "test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			FailureExpression:               "size(vmi.status.conditions)",
+		}),
+		Entry("success condition and expression", "only one of success-condition|success-expression options is allowed", &parse.CLIOptions{
+			VirtualMachineInstanceName:      "test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			SuccessCondition:                "status.phase == Succeeded",
+			SuccessExpression:               "vmi.status.phase == 'Succeeded'",
+		}),
+		Entry("failure condition and expression", "only one of failure-condition|failure-expression options is allowed", &parse.CLIOptions{
+			VirtualMachineInstanceName:      "test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			FailureCondition:                "status.phase == Failed",
+			FailureExpression:               "vmi.status.phase == 'Failed'",
+		}),
+		Entry("invalid deletion policy", "invalid option deletion-policy ignore, only wait|fail|succeed is allowed", &parse.CLIOptions{
+			VirtualMachineInstanceName:      "test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			DeletionPolicy:                  "ignore",
+		}),
+		Entry("invalid timeout", "invalid timeout value 10: should be a non-negative duration in a 3h2m1s format", &parse.CLIOptions{
+			VirtualMachineInstanceName:      "test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			Timeout:                         "10",
+		}),
+		Entry("invalid reevaluation interval", "invalid reevaluation-interval value 1 minute: should be a non-negative duration in a 3h2m1s format", &parse.CLIOptions{
+			VirtualMachineInstanceName:      "test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			ReevaluationInterval:            "1 minute",
+		}),
+		Entry("negative timeout", "invalid timeout value -5m: should be a non-negative duration in a 3h2m1s format", &parse.CLIOptions{
+			VirtualMachineInstanceName:      "test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			Timeout:                         "-5m",
+		}),
 	)
 
 	DescribeTable("Parses and returns correct values", func(options *parse.CLIOptions, expectedOptions map[string]interface{}) {
@@ -68,6 +144,13 @@ var _ = Describe("CLIOptions", func() {
 			"GetFailureCondition":                "",
 			"GetSuccessRequirements":             labels.Requirements(nil),
 			"GetFailureRequirements":             labels.Requirements(nil),
+			"GetSuccessExpression":               "",
+			"GetFailureExpression":               "",
+			"GetTimeout":                         time.Duration(0),
+			"GetReevaluationInterval":            30 * time.Second,
+			"GetSuccessQuorum":                   0,
+			"GetFailurePolicy":                   constants.FailFastFailurePolicy,
+			"GetDeletionPolicy":                  constants.WaitDeletionPolicy,
 			"GetDebugLevel":                      zapcore.InfoLevel,
 		}),
 		Entry("handles cli arguments + trim", &parse.CLIOptions{
@@ -75,6 +158,9 @@ var _ = Describe("CLIOptions", func() {
 			VirtualMachineInstanceNamespace: "  " + defaultNS,
 			SuccessCondition:                " metadata.name in (fedora, ubuntu), status.phase == Succeeded  ",
 			FailureCondition:                " status.phase in (Failed, Unknown)",
+			DeletionPolicy:                  " fail ",
+			Timeout:                         " 1h30m ",
+			ReevaluationInterval:            " 0 ",
 			Debug:                           true,
 		}, map[string]interface{}{
 			"GetVirtualMachineInstanceName":      "test",
@@ -88,7 +174,41 @@ var _ = Describe("CLIOptions", func() {
 			"GetFailureRequirements": labels.Requirements{
 				utilstest.GetRequirement("status.phase", selection.In, []string{"Failed", "Unknown"}),
 			},
-			"GetDebugLevel": zapcore.DebugLevel,
+			"GetDeletionPolicy":       constants.FailDeletionPolicy,
+			"GetTimeout":              90 * time.Minute,
+			"GetReevaluationInterval": time.Duration(0),
+			"GetDebugLevel":           zapcore.DebugLevel,
+		}),
+		Entry("handles selector and quorum", &parse.CLIOptions{
+			VirtualMachineInstanceSelector:  " app=test, tier in (db) ",
+			VirtualMachineInstanceNamespace: defaultNS,
+			SuccessQuorum:                   " 2",
+			FailurePolicy:                   "quorum ",
+		}, map[string]interface{}{
+			"GetVirtualMachineInstanceName":     "",
+			"GetVirtualMachineInstanceSelector": "app=test, tier in (db)",
+			"GetSuccessQuorum":                  2,
+			"GetFailurePolicy":                  constants.QuorumFailurePolicy,
+		}),
+		Entry("handles all success quorum", &parse.CLIOptions{
+			VirtualMachineInstanceSelector:  "app=test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			SuccessQuorum:                   "all",
+			FailurePolicy:                   "fail-fast",
+		}, map[string]interface{}{
+			"GetSuccessQuorum": 0,
+			"GetFailurePolicy": constants.FailFastFailurePolicy,
+		}),
+		Entry("handles expressions", &parse.CLIOptions{
+			VirtualMachineInstanceName:      "test",
+			VirtualMachineInstanceNamespace: defaultNS,
+			SuccessExpression:               " vmi.status.conditions.exists(c, c.type == 'Ready' && c.status == 'True') ",
+			FailureExpression:               "vmi.status.phase in ['Failed', 'Unknown']",
+		}, map[string]interface{}{
+			"GetSuccessExpression":   "vmi.status.conditions.exists(c, c.type == 'Ready' && c.status == 'True')",
+			"GetFailureExpression":   "vmi.status.phase in ['Failed', 'Unknown']",
+			"GetSuccessRequirements": labels.Requirements(nil),
+			"GetFailureRequirements": labels.Requirements(nil),
 		}),
 	)
 })
@@ -3,20 +3,36 @@ package parse
 import (
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/env"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/zerrors"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/expressions"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/requirements"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/validation"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func (c *CLIOptions) trimSpaces() {
-	for _, strVariablePtr := range []*string{&c.VirtualMachineInstanceName, &c.VirtualMachineInstanceNamespace, &c.SuccessCondition, &c.FailureCondition} {
+	for _, strVariablePtr := range []*string{&c.VirtualMachineInstanceName, &c.VirtualMachineInstanceNamespace, &c.VirtualMachineInstanceSelector, &c.ResourceKind, &c.SuccessCondition, &c.FailureCondition,
+		&c.SuccessExpression, &c.FailureExpression, &c.SuccessQuorum, &c.FailurePolicy, &c.DeletionPolicy, &c.Timeout, &c.ReevaluationInterval} {
 		*strVariablePtr = strings.TrimSpace(*strVariablePtr)
 	}
 }
 
 func (c *CLIOptions) validateNames() error {
-	if c.VirtualMachineInstanceName == "" {
-		return zerrors.NewMissingRequiredError("%v should not be empty", vmiNameOptionName)
+	if c.VirtualMachineInstanceName == "" && c.VirtualMachineInstanceSelector == "" {
+		return zerrors.NewMissingRequiredError("%v or %v should not be empty", vmiNameOptionName, vmiSelectorOptionName)
+	}
+
+	if c.VirtualMachineInstanceName != "" && c.VirtualMachineInstanceSelector != "" {
+		return zerrors.NewMissingRequiredError("only one of %v|%v options is allowed", vmiNameOptionName, vmiSelectorOptionName)
+	}
+
+	if c.VirtualMachineInstanceSelector != "" {
+		if _, err := labels.Parse(c.VirtualMachineInstanceSelector); err != nil {
+			return zerrors.NewMissingRequiredError("invalid %v value: %v", vmiSelectorOptionName, err.Error())
+		}
 	}
 
 	for optionName, optionValue := range map[string]string{
@@ -43,6 +59,42 @@ func (c *CLIOptions) resolveDefaultNamespaces() error {
 	return nil
 }
 
+func (c *CLIOptions) validateQuorum() error {
+	if c.VirtualMachineInstanceSelector == "" {
+		for optionName, optionValue := range map[string]string{
+			successQuorumOptionName: c.SuccessQuorum,
+			failurePolicyOptionName: c.FailurePolicy,
+		} {
+			if optionValue != "" {
+				return zerrors.NewMissingRequiredError("%v option is allowed only with %v option", optionName, vmiSelectorOptionName)
+			}
+		}
+	}
+
+	if c.SuccessQuorum != "" && c.SuccessQuorum != constants.AllSuccessQuorum {
+		if successQuorum, err := strconv.Atoi(c.SuccessQuorum); err != nil || successQuorum <= 0 {
+			return zerrors.NewMissingRequiredError("invalid %v value %v: should be %v or a positive number", successQuorumOptionName, c.SuccessQuorum, constants.AllSuccessQuorum)
+		}
+	}
+
+	switch c.GetFailurePolicy() {
+	case constants.FailFastFailurePolicy, constants.QuorumFailurePolicy:
+	default:
+		return zerrors.NewMissingRequiredError("invalid option %v %v, only %v|%v is allowed", failurePolicyOptionName, c.FailurePolicy, constants.FailFastFailurePolicy, constants.QuorumFailurePolicy)
+	}
+	return nil
+}
+
+func (c *CLIOptions) validateDeletionPolicy() error {
+	switch c.GetDeletionPolicy() {
+	case constants.WaitDeletionPolicy, constants.FailDeletionPolicy, constants.SucceedDeletionPolicy:
+	default:
+		return zerrors.NewMissingRequiredError("invalid option %v %v, only %v|%v|%v is allowed", deletionPolicyOptionName, c.DeletionPolicy,
+			constants.WaitDeletionPolicy, constants.FailDeletionPolicy, constants.SucceedDeletionPolicy)
+	}
+	return nil
+}
+
 func (c *CLIOptions) validateConditions() error {
 	for conditionName, condition := range map[string]string{
 		successConditionOptionName: c.SuccessCondition,
@@ -55,3 +107,40 @@ func (c *CLIOptions) validateConditions() error {
 	}
 	return nil
 }
+
+func (c *CLIOptions) validateExpressions() error {
+	if c.SuccessCondition != "" && c.SuccessExpression != "" {
+		return zerrors.NewMissingRequiredError("only one of %v|%v options is allowed", successConditionOptionName, successExpressionOptionName)
+	}
+
+	if c.FailureCondition != "" && c.FailureExpression != "" {
+		return zerrors.NewMissingRequiredError("only one of %v|%v options is allowed", failureConditionOptionName, failureExpressionOptionName)
+	}
+
+	for _, expression := range []struct {
+		optionName string
+		value      string
+	}{
+		{successExpressionOptionName, c.SuccessExpression},
+		{failureExpressionOptionName, c.FailureExpression},
+	} {
+		if _, err := expressions.Compile(expression.value); err != nil {
+			return zerrors.NewMissingRequiredError("%v: %v", expression.optionName, err.Error())
+		}
+	}
+	return nil
+}
+
+func (c *CLIOptions) validateDurations() error {
+	for optionName, optionValue := range map[string]string{
+		timeoutOptionName:              c.Timeout,
+		reevaluationIntervalOptionName: c.ReevaluationInterval,
+	} {
+		if optionValue != "" {
+			if duration, err := time.ParseDuration(optionValue); err != nil || duration < 0 {
+				return zerrors.NewSoftError("invalid %v value %v: should be a non-negative duration in a 3h2m1s format", optionName, optionValue)
+			}
+		}
+	}
+	return nil
+}
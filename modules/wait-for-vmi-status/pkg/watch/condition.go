@@ -0,0 +1,54 @@
+package watch
+
+import (
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/expressions"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/requirements"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/utils/log"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// condition is either a label selector or a CEL expression evaluated against the watched object
+type condition struct {
+	selector     string
+	requirements labels.Requirements
+	expression   *expressions.Expression
+}
+
+func newCondition(selector string, requirements labels.Requirements, expression *expressions.Expression) *condition {
+	return &condition{
+		selector:     selector,
+		requirements: requirements,
+		expression:   expression,
+	}
+}
+
+func (c *condition) isEmpty() bool {
+	return len(c.requirements) == 0 && c.expression == nil
+}
+
+func (c *condition) matches(obj interface{}) bool {
+	if c.expression != nil {
+		matched, err := c.expression.Matches(obj)
+		if err != nil {
+			// e.g. the status of the VMI is not populated yet
+			log.Logger().Debug("could not evaluate expression", zap.Stringer("expression", c.expression), zap.Error(err))
+			return false
+		}
+		return matched
+	}
+
+	if object, ok := obj.(*unstructured.Unstructured); ok {
+		// jsonpath keys are looked up in the content of the object
+		return requirements.MatchesRequirements(object.Object, c.requirements)
+	}
+	return requirements.MatchesRequirements(obj, c.requirements)
+}
+
+func (c *condition) String() string {
+	if c.expression != nil {
+		return c.expression.String()
+	}
+	return c.selector
+}
@@ -0,0 +1,39 @@
+package watch
+
+import (
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/constants"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+// getDeletedObject returns the name of the deleted object and its final state. The final state is nil if the deletion
+// was missed by the watch and the informer only knows the key of the object.
+func getDeletedObject(obj interface{}) (string, *unstructured.Unstructured) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		if object, ok := tombstone.Obj.(*unstructured.Unstructured); ok {
+			return object.GetName(), object
+		}
+		_, name, err := cache.SplitMetaNamespaceKey(tombstone.Key)
+		if err != nil {
+			return "", nil
+		}
+		return name, nil
+	}
+
+	if object, ok := obj.(*unstructured.Unstructured); ok {
+		return object.GetName(), object
+	}
+	return "", nil
+}
+
+// getDeletionState returns the state of a deleted object
+func getDeletionState(deletionPolicy constants.DeletionPolicy) objectState {
+	switch deletionPolicy {
+	case constants.FailDeletionPolicy:
+		return failureObjectState
+	case constants.SucceedDeletionPolicy:
+		return successObjectState
+	default:
+		return waitingObjectState
+	}
+}
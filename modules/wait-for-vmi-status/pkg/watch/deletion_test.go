@@ -0,0 +1,40 @@
+package watch_test
+
+import (
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/watch"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+var _ = Describe("Deletion", func() {
+	newObject := func(name string) *unstructured.Unstructured {
+		object := &unstructured.Unstructured{}
+		object.SetName(name)
+		object.SetNamespace("default")
+		return object
+	}
+
+	DescribeTable("gets deleted object", func(obj interface{}, expectedName string, expectedObject *unstructured.Unstructured) {
+		name, object := watch.GetDeletedObject(obj)
+		Expect(name).To(Equal(expectedName))
+		Expect(object).To(Equal(expectedObject))
+	},
+		Entry("object", newObject("test"), "test", newObject("test")),
+		Entry("tombstone with final state", cache.DeletedFinalStateUnknown{Key: "default/test", Obj: newObject("test")}, "test", newObject("test")),
+		Entry("tombstone without final state", cache.DeletedFinalStateUnknown{Key: "default/test"}, "test", nil),
+		Entry("tombstone of cluster scoped object", cache.DeletedFinalStateUnknown{Key: "test"}, "test", nil),
+		Entry("invalid tombstone", cache.DeletedFinalStateUnknown{Key: "a/b/c"}, "", nil),
+		Entry("unknown object", "test", "", nil),
+	)
+
+	DescribeTable("gets deletion state", func(deletionPolicy constants.DeletionPolicy, expectedState watch.ObjectState) {
+		Expect(watch.GetDeletionState(deletionPolicy)).To(Equal(expectedState))
+	},
+		Entry("wait", constants.WaitDeletionPolicy, watch.WaitingObjectState),
+		Entry("fail", constants.FailDeletionPolicy, watch.FailureObjectState),
+		Entry("succeed", constants.SucceedDeletionPolicy, watch.SuccessObjectState),
+	)
+})
@@ -0,0 +1,92 @@
+package watch
+
+import (
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/utils/parse"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+var GetResults = getResults
+var ResolveResource = resolveResource
+
+type Resource = resource
+
+type ObjectState = objectState
+
+const (
+	WaitingObjectState = waitingObjectState
+	SuccessObjectState = successObjectState
+	FailureObjectState = failureObjectState
+)
+
+type Quorum = quorum
+
+func NewQuorum(successQuorum int, failurePolicy constants.FailurePolicy) *Quorum {
+	return newQuorum(successQuorum, failurePolicy)
+}
+
+func (q *quorum) Update(name string, state ObjectState, phase string) {
+	q.update(name, state, phase)
+}
+
+func (q *quorum) Decide() (bool, bool) {
+	return q.decide()
+}
+
+func (q *quorum) UpdateState(name string, state ObjectState) {
+	q.updateState(name, state)
+}
+
+var GetDeletedObject = getDeletedObject
+var GetDeletionState = getDeletionState
+
+type Transition = transition
+
+type History = history
+
+func NewHistory(now func() time.Time) *History {
+	h := newHistory()
+	h.now = now
+	return h
+}
+
+func (h *history) Record(object *unstructured.Unstructured) (Transition, bool) {
+	return h.record(object)
+}
+
+func (h *history) RecordDeletion(name string) (Transition, bool) {
+	return h.recordDeletion(name)
+}
+
+const MaxHistorySize = maxHistorySize
+
+func NewWatchFacadeWithoutClients(clioptions *parse.CLIOptions) *WatchFacade {
+	return newWatchFacade(clioptions, nil, nil, virtualMachineInstanceResource)
+}
+
+func (f *WatchFacade) WaitForConditionsWith(listerWatcher cache.ListerWatcher) (bool, error) {
+	return f.waitForConditions(listerWatcher)
+}
+
+func (f *WatchFacade) GetStates() string {
+	return f.quorum.String()
+}
+
+type WatchStats struct {
+	ListErrors       int64
+	WatchErrors      int64
+	ExpiredWatches   int64
+	RestartedWatches int64
+}
+
+func (f *WatchFacade) GetWatchStats() WatchStats {
+	return WatchStats{
+		ListErrors:       f.stats.listErrors,
+		WatchErrors:      f.stats.watchErrors,
+		ExpiredWatches:   f.stats.expiredWatches,
+		RestartedWatches: f.stats.restartedWatches,
+	}
+}
@@ -0,0 +1,108 @@
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// maxHistorySize limits the size of the history result, so it fits into the termination message of the task
+const maxHistorySize = 2048
+
+// transition is a change of the phase or conditions of an object
+type transition struct {
+	Time       string   `json:"time"`
+	Name       string   `json:"name"`
+	Phase      string   `json:"phase,omitempty"`
+	Conditions []string `json:"conditions,omitempty"`
+	Deleted    bool     `json:"deleted,omitempty"`
+}
+
+func (t transition) String() string {
+	if t.Deleted {
+		return fmt.Sprintf("%v: deleted", t.Name)
+	}
+
+	phase := t.Phase
+	if phase == "" {
+		phase = "no phase"
+	}
+	if len(t.Conditions) == 0 {
+		return fmt.Sprintf("%v: %v", t.Name, phase)
+	}
+	return fmt.Sprintf("%v: %v (%v)", t.Name, phase, strings.Join(t.Conditions, ", "))
+}
+
+// history records the transitions of the watched objects
+type history struct {
+	now func() time.Time
+
+	lock            sync.Mutex
+	transitions     []transition
+	lastTransitions map[string]transition
+}
+
+func newHistory() *history {
+	return &history{
+		now:             time.Now,
+		lastTransitions: map[string]transition{},
+	}
+}
+
+// record returns the new transition of the object and true if its phase or conditions changed since the last transition
+func (h *history) record(object *unstructured.Unstructured) (transition, bool) {
+	return h.add(transition{
+		Name:       object.GetName(),
+		Phase:      getPhase(object),
+		Conditions: getConditions(object),
+	})
+}
+
+// recordDeletion returns the transition of the deleted object
+func (h *history) recordDeletion(name string) (transition, bool) {
+	return h.add(transition{
+		Name:    name,
+		Deleted: true,
+	})
+}
+
+func (h *history) add(newTransition transition) (transition, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if lastTransition, ok := h.lastTransitions[newTransition.Name]; ok {
+		lastTransition.Time = ""
+		if reflect.DeepEqual(lastTransition, newTransition) {
+			return transition{}, false
+		}
+	}
+	h.lastTransitions[newTransition.Name] = newTransition
+
+	newTransition.Time = h.now().UTC().Format(time.RFC3339)
+	h.transitions = append(h.transitions, newTransition)
+	return newTransition, true
+}
+
+// String returns the transitions as a JSON list. The oldest transitions are left out if the list exceeds maxHistorySize.
+func (h *history) String() string {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	transitions := h.transitions
+	if transitions == nil {
+		transitions = []transition{}
+	}
+
+	// marshaling of transitions can not fail
+	result, _ := json.Marshal(transitions)
+	for len(result) > maxHistorySize && len(transitions) > 1 {
+		transitions = transitions[1:]
+		result, _ = json.Marshal(transitions)
+	}
+	return string(result)
+}
@@ -0,0 +1,99 @@
+package watch_test
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/watch"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("History", func() {
+	var now time.Time
+	var history *watch.History
+
+	newObject := func(name, phase string, conditions ...map[string]interface{}) *unstructured.Unstructured {
+		status := map[string]interface{}{}
+		if phase != "" {
+			status["phase"] = phase
+		}
+		if len(conditions) > 0 {
+			statusConditions := make([]interface{}, 0, len(conditions))
+			for _, condition := range conditions {
+				statusConditions = append(statusConditions, condition)
+			}
+			status["conditions"] = statusConditions
+		}
+		object := &unstructured.Unstructured{Object: map[string]interface{}{"status": status}}
+		object.SetName(name)
+		return object
+	}
+
+	ready := map[string]interface{}{"type": "Ready", "status": "True"}
+	notReady := map[string]interface{}{"type": "Ready", "status": "False", "reason": "GuestNotRunning"}
+
+	BeforeEach(func() {
+		now = time.Date(2022, 10, 5, 12, 0, 0, 0, time.UTC)
+		history = watch.NewHistory(func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		})
+	})
+
+	It("records transitions", func() {
+		transition, ok := history.Record(newObject("vmi-a", "Scheduling", notReady))
+		Expect(ok).To(BeTrue())
+		Expect(transition.String()).To(Equal("vmi-a: Scheduling (Ready=False: GuestNotRunning)"))
+
+		_, ok = history.Record(newObject("vmi-a", "Scheduling", notReady))
+		Expect(ok).To(BeFalse())
+
+		transition, ok = history.Record(newObject("vmi-a", "Running", ready))
+		Expect(ok).To(BeTrue())
+		Expect(transition.String()).To(Equal("vmi-a: Running (Ready=True)"))
+
+		transition, ok = history.Record(newObject("vmi-b", ""))
+		Expect(ok).To(BeTrue())
+		Expect(transition.String()).To(Equal("vmi-b: no phase"))
+
+		transition, ok = history.RecordDeletion("vmi-a")
+		Expect(ok).To(BeTrue())
+		Expect(transition.String()).To(Equal("vmi-a: deleted"))
+
+		_, ok = history.RecordDeletion("vmi-a")
+		Expect(ok).To(BeFalse())
+
+		transition, ok = history.Record(newObject("vmi-a", "Pending"))
+		Expect(ok).To(BeTrue())
+		Expect(transition.String()).To(Equal("vmi-a: Pending"))
+
+		Expect(history.String()).To(MatchJSON(`[
+			{"time": "2022-10-05T12:00:01Z", "name": "vmi-a", "phase": "Scheduling", "conditions": ["Ready=False: GuestNotRunning"]},
+			{"time": "2022-10-05T12:00:02Z", "name": "vmi-a", "phase": "Running", "conditions": ["Ready=True"]},
+			{"time": "2022-10-05T12:00:03Z", "name": "vmi-b"},
+			{"time": "2022-10-05T12:00:04Z", "name": "vmi-a", "deleted": true},
+			{"time": "2022-10-05T12:00:05Z", "name": "vmi-a", "phase": "Pending"}
+		]`))
+	})
+
+	It("returns empty history", func() {
+		Expect(history.String()).To(Equal("[]"))
+	})
+
+	It("leaves out the oldest transitions", func() {
+		for i := 0; i < 100; i++ {
+			history.Record(newObject("vmi-a", "Phase"+strings.Repeat("a", i%2)))
+		}
+
+		result := history.String()
+		Expect(len(result)).To(BeNumerically("<=", watch.MaxHistorySize))
+
+		var transitions []map[string]interface{}
+		Expect(json.Unmarshal([]byte(result), &transitions)).To(Succeed())
+		Expect(len(transitions)).To(BeNumerically(">", 1))
+		Expect(transitions[len(transitions)-1]["time"]).To(Equal(now.Format(time.RFC3339)))
+	})
+})
@@ -0,0 +1,116 @@
+package watch
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/utils/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	watchapi "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	api "k8s.io/kubernetes/pkg/apis/core"
+)
+
+// newListerWatcher lists and watches a single object of the resource by its name or the objects matching the label selector
+func newListerWatcher(resourceClient dynamic.ResourceInterface, name, labelSelector string) cache.ListerWatcher {
+	var fieldSelector string
+	if name != "" {
+		fieldSelector = fields.OneTermEqualSelector(api.ObjectNameField, name).String()
+	}
+
+	return &cache.ListWatch{
+		ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			options.LabelSelector = labelSelector
+			return resourceClient.List(context.Background(), options)
+		},
+		WatchFunc: func(options v1.ListOptions) (watchapi.Interface, error) {
+			options.FieldSelector = fieldSelector
+			options.LabelSelector = labelSelector
+			return resourceClient.Watch(context.Background(), options)
+		},
+	}
+}
+
+// watchStats counts the errors the informer recovered from by listing or watching the objects again
+type watchStats struct {
+	listErrors       int64
+	watchErrors      int64
+	expiredWatches   int64
+	restartedWatches int64
+}
+
+func (s *watchStats) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddInt64("listErrors", atomic.LoadInt64(&s.listErrors))
+	encoder.AddInt64("watchErrors", atomic.LoadInt64(&s.watchErrors))
+	encoder.AddInt64("expiredWatches", atomic.LoadInt64(&s.expiredWatches))
+	encoder.AddInt64("restartedWatches", atomic.LoadInt64(&s.restartedWatches))
+	return nil
+}
+
+// countingListerWatcher counts the errors of the wrapped ListerWatcher and the restarts of the watch.
+// The watch is restarted after errors, e.g. when the API server restarts, and also regularly by the API server.
+// Forbidden list errors are not retried, because they do not recover without changing the RBAC permissions.
+type countingListerWatcher struct {
+	cache.ListerWatcher
+	stats       *watchStats
+	watches     int64
+	onForbidden func(err error)
+}
+
+func newCountingListerWatcher(listerWatcher cache.ListerWatcher, stats *watchStats, onForbidden func(err error)) *countingListerWatcher {
+	return &countingListerWatcher{
+		ListerWatcher: listerWatcher,
+		stats:         stats,
+		onForbidden:   onForbidden,
+	}
+}
+
+func (lw *countingListerWatcher) List(options v1.ListOptions) (runtime.Object, error) {
+	list, err := lw.ListerWatcher.List(options)
+	if err != nil {
+		atomic.AddInt64(&lw.stats.listErrors, 1)
+		if apierrors.IsForbidden(err) {
+			lw.onForbidden(err)
+			return list, err
+		}
+		log.Logger().Warn("could not list objects, retrying", zap.Error(err))
+	}
+	return list, err
+}
+
+func (lw *countingListerWatcher) Watch(options v1.ListOptions) (watchapi.Interface, error) {
+	if atomic.AddInt64(&lw.watches, 1) > 1 {
+		atomic.AddInt64(&lw.stats.restartedWatches, 1)
+	}
+
+	watcher, err := lw.ListerWatcher.Watch(options)
+	if err != nil {
+		lw.countWatchError(err)
+		return nil, err
+	}
+
+	return watchapi.Filter(watcher, func(event watchapi.Event) (watchapi.Event, bool) {
+		if event.Type == watchapi.Error {
+			lw.countWatchError(apierrors.FromObject(event.Object))
+		}
+		return event, true
+	}), nil
+}
+
+func (lw *countingListerWatcher) countWatchError(err error) {
+	// the resource version of the watch is too old and the objects are listed again
+	if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+		atomic.AddInt64(&lw.stats.expiredWatches, 1)
+		log.Logger().Debug("watch expired, listing again", zap.Error(err))
+		return
+	}
+	atomic.AddInt64(&lw.stats.watchErrors, 1)
+	log.Logger().Warn("watch failed, retrying", zap.Error(err))
+}
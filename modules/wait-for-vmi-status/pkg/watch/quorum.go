@@ -0,0 +1,123 @@
+package watch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/constants"
+)
+
+type objectState string
+
+const (
+	waitingObjectState objectState = "waiting"
+	successObjectState objectState = "success"
+	failureObjectState objectState = "failure"
+)
+
+type objectStatus struct {
+	state objectState
+	phase string
+}
+
+// quorum tracks the states of the watched objects and decides when the wait is over
+type quorum struct {
+	// successQuorum of zero requires all objects to fulfill the success condition
+	successQuorum int
+	failurePolicy constants.FailurePolicy
+
+	lock     sync.Mutex
+	statuses map[string]objectStatus
+}
+
+func newQuorum(successQuorum int, failurePolicy constants.FailurePolicy) *quorum {
+	return &quorum{
+		successQuorum: successQuorum,
+		failurePolicy: failurePolicy,
+		statuses:      map[string]objectStatus{},
+	}
+}
+
+func (q *quorum) update(name string, state objectState, phase string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.statuses[name] = objectStatus{state: state, phase: phase}
+}
+
+// updateState updates the state of the object and keeps its last known phase
+func (q *quorum) updateState(name string, state objectState) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	status := q.statuses[name]
+	status.state = state
+	q.statuses[name] = status
+}
+
+// decide returns true as the first value once the wait is over and whether it succeeded as the second value
+func (q *quorum) decide() (bool, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	var succeeded, failed int
+	for _, status := range q.statuses {
+		switch status.state {
+		case successObjectState:
+			succeeded++
+		case failureObjectState:
+			failed++
+		}
+	}
+
+	required := q.successQuorum
+	if required == 0 {
+		required = len(q.statuses)
+		if required == 0 {
+			// no objects do not fulfill the quorum of all objects
+			required = 1
+		}
+	}
+
+	if succeeded >= required {
+		return true, true
+	}
+
+	if failed > 0 {
+		switch q.failurePolicy {
+		case constants.QuorumFailurePolicy:
+			if len(q.statuses)-failed < required {
+				return true, false
+			}
+		default:
+			return true, false
+		}
+	}
+
+	return false, false
+}
+
+// String returns the states of the objects in the NAME=STATE[: PHASE] format separated by newlines
+func (q *quorum) String() string {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	names := make([]string, 0, len(q.statuses))
+	for name := range q.statuses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		status := q.statuses[name]
+		line := fmt.Sprintf("%v=%v", name, status.state)
+		if status.phase != "" {
+			line += ": " + status.phase
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
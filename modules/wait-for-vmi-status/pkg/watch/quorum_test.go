@@ -0,0 +1,67 @@
+package watch_test
+
+import (
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/constants"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/watch"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Quorum", func() {
+	DescribeTable("decides", func(successQuorum int, failurePolicy constants.FailurePolicy, states []watch.ObjectState, expectedDone, expectedSuccess bool) {
+		quorum := watch.NewQuorum(successQuorum, failurePolicy)
+		for idx, state := range states {
+			quorum.Update(string(rune('a'+idx)), state, "")
+		}
+
+		done, success := quorum.Decide()
+		Expect(done).To(Equal(expectedDone))
+		Expect(success).To(Equal(expectedSuccess))
+	},
+		Entry("no objects", 0, constants.FailFastFailurePolicy, nil, false, false),
+		Entry("no objects with a number", 1, constants.QuorumFailurePolicy, nil, false, false),
+		Entry("single success", 0, constants.FailFastFailurePolicy, []watch.ObjectState{watch.SuccessObjectState}, true, true),
+		Entry("single failure", 0, constants.FailFastFailurePolicy, []watch.ObjectState{watch.FailureObjectState}, true, false),
+		Entry("single waiting", 0, constants.FailFastFailurePolicy, []watch.ObjectState{watch.WaitingObjectState}, false, false),
+		Entry("all succeeded", 0, constants.FailFastFailurePolicy,
+			[]watch.ObjectState{watch.SuccessObjectState, watch.SuccessObjectState, watch.SuccessObjectState}, true, true),
+		Entry("not all succeeded", 0, constants.FailFastFailurePolicy,
+			[]watch.ObjectState{watch.SuccessObjectState, watch.WaitingObjectState, watch.SuccessObjectState}, false, false),
+		Entry("any failed with all", 0, constants.QuorumFailurePolicy,
+			[]watch.ObjectState{watch.SuccessObjectState, watch.FailureObjectState, watch.WaitingObjectState}, true, false),
+		Entry("at least reached", 2, constants.FailFastFailurePolicy,
+			[]watch.ObjectState{watch.SuccessObjectState, watch.WaitingObjectState, watch.SuccessObjectState}, true, true),
+		Entry("at least not reached", 2, constants.FailFastFailurePolicy,
+			[]watch.ObjectState{watch.SuccessObjectState, watch.WaitingObjectState, watch.WaitingObjectState}, false, false),
+		Entry("at least reached despite failure", 2, constants.QuorumFailurePolicy,
+			[]watch.ObjectState{watch.SuccessObjectState, watch.FailureObjectState, watch.SuccessObjectState}, true, true),
+		Entry("fail fast", 2, constants.FailFastFailurePolicy,
+			[]watch.ObjectState{watch.SuccessObjectState, watch.FailureObjectState, watch.WaitingObjectState}, true, false),
+		Entry("quorum still reachable", 2, constants.QuorumFailurePolicy,
+			[]watch.ObjectState{watch.SuccessObjectState, watch.FailureObjectState, watch.WaitingObjectState}, false, false),
+		Entry("quorum unreachable", 2, constants.QuorumFailurePolicy,
+			[]watch.ObjectState{watch.WaitingObjectState, watch.FailureObjectState, watch.FailureObjectState}, true, false),
+	)
+
+	It("returns states of the objects", func() {
+		quorum := watch.NewQuorum(0, constants.FailFastFailurePolicy)
+		quorum.Update("vmi-b", watch.FailureObjectState, "Failed")
+		quorum.Update("vmi-a", watch.WaitingObjectState, "Running")
+		quorum.Update("vmi-c", watch.WaitingObjectState, "")
+		quorum.Update("vmi-a", watch.SuccessObjectState, "Succeeded")
+
+		Expect(quorum.String()).To(Equal("vmi-a=success: Succeeded\nvmi-b=failure: Failed\nvmi-c=waiting"))
+	})
+
+	It("keeps the phase of deleted objects", func() {
+		quorum := watch.NewQuorum(0, constants.FailFastFailurePolicy)
+		quorum.Update("vmi-a", watch.WaitingObjectState, "Running")
+		quorum.UpdateState("vmi-a", watch.FailureObjectState)
+		quorum.UpdateState("vmi-b", watch.WaitingObjectState)
+
+		Expect(quorum.String()).To(Equal("vmi-a=failure: Running\nvmi-b=waiting"))
+		done, success := quorum.Decide()
+		Expect(done).To(BeTrue())
+		Expect(success).To(BeFalse())
+	})
+})
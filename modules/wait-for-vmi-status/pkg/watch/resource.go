@@ -0,0 +1,57 @@
+package watch
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// resource is a resource which can be watched
+type resource struct {
+	schema.GroupVersionResource
+	Namespaced bool
+}
+
+var virtualMachineInstanceResource = &resource{
+	GroupVersionResource: kubevirtv1.SchemeGroupVersion.WithResource("virtualmachineinstances"),
+	Namespaced:           true,
+}
+
+// resolveResource resolves a kind, a resource or a short name optionally qualified with a version and a group
+// (e.g. VirtualMachine, datavolumes.cdi.kubevirt.io or pvc) to a resource served by the cluster.
+// Defaults to VirtualMachineInstances.
+func resolveResource(discoveryClient discovery.DiscoveryInterface, resourceKind string) (*resource, error) {
+	if resourceKind == "" {
+		return virtualMachineInstanceResource, nil
+	}
+
+	mapper := restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)), discoveryClient)
+
+	var gvk schema.GroupVersionKind
+	fullySpecifiedGVR, groupResource := schema.ParseResourceArg(strings.ToLower(resourceKind))
+	if fullySpecifiedGVR != nil {
+		gvk, _ = mapper.KindFor(*fullySpecifiedGVR)
+	}
+	if gvk.Empty() {
+		var err error
+		if gvk, err = mapper.KindFor(groupResource.WithVersion("")); err != nil {
+			return nil, fmt.Errorf("could not find resource %v: %v", resourceKind, err)
+		}
+	}
+
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("could not find resource %v: %v", resourceKind, err)
+	}
+
+	return &resource{
+		GroupVersionResource: mapping.Resource,
+		Namespaced:           mapping.Scope.Name() == meta.RESTScopeNameNamespace,
+	}, nil
+}
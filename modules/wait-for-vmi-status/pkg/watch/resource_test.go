@@ -0,0 +1,85 @@
+package watch_test
+
+import (
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/watch"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Resource", func() {
+	var discoveryClient *fakediscovery.FakeDiscovery
+
+	BeforeEach(func() {
+		discoveryClient = &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+		discoveryClient.Resources = []*v1.APIResourceList{
+			{
+				GroupVersion: "v1",
+				APIResources: []v1.APIResource{
+					{Name: "persistentvolumeclaims", SingularName: "persistentvolumeclaim", Namespaced: true, Kind: "PersistentVolumeClaim", ShortNames: []string{"pvc"}},
+					{Name: "namespaces", SingularName: "namespace", Namespaced: false, Kind: "Namespace", ShortNames: []string{"ns"}},
+				},
+			},
+			{
+				GroupVersion: "kubevirt.io/v1",
+				APIResources: []v1.APIResource{
+					{Name: "virtualmachines", SingularName: "virtualmachine", Namespaced: true, Kind: "VirtualMachine", ShortNames: []string{"vm", "vms"}},
+					{Name: "virtualmachineinstances", SingularName: "virtualmachineinstance", Namespaced: true, Kind: "VirtualMachineInstance", ShortNames: []string{"vmi", "vmis"}},
+				},
+			},
+			{
+				GroupVersion: "cdi.kubevirt.io/v1beta1",
+				APIResources: []v1.APIResource{
+					{Name: "datavolumes", SingularName: "datavolume", Namespaced: true, Kind: "DataVolume", ShortNames: []string{"dv", "dvs"}},
+					{Name: "datasources", SingularName: "datasource", Namespaced: true, Kind: "DataSource", ShortNames: []string{"das"}},
+				},
+			},
+			{
+				GroupVersion: "snapshot.kubevirt.io/v1alpha1",
+				APIResources: []v1.APIResource{
+					{Name: "virtualmachinesnapshots", SingularName: "virtualmachinesnapshot", Namespaced: true, Kind: "VirtualMachineSnapshot", ShortNames: []string{"vmsnapshot"}},
+				},
+			},
+		}
+	})
+
+	DescribeTable("resolves resources", func(resourceKind string, expectedResource *watch.Resource) {
+		resource, err := watch.ResolveResource(discoveryClient, resourceKind)
+		Expect(err).Should(Succeed())
+		Expect(resource).To(Equal(expectedResource))
+	},
+		Entry("defaults to vmi", "", &watch.Resource{
+			GroupVersionResource: schema.GroupVersionResource{Group: "kubevirt.io", Version: "v1", Resource: "virtualmachineinstances"},
+			Namespaced:           true,
+		}),
+		Entry("kind", "VirtualMachine", &watch.Resource{
+			GroupVersionResource: schema.GroupVersionResource{Group: "kubevirt.io", Version: "v1", Resource: "virtualmachines"},
+			Namespaced:           true,
+		}),
+		Entry("resource with group", "datavolumes.cdi.kubevirt.io", &watch.Resource{
+			GroupVersionResource: schema.GroupVersionResource{Group: "cdi.kubevirt.io", Version: "v1beta1", Resource: "datavolumes"},
+			Namespaced:           true,
+		}),
+		Entry("kind with version and group", "VirtualMachineSnapshot.v1alpha1.snapshot.kubevirt.io", &watch.Resource{
+			GroupVersionResource: schema.GroupVersionResource{Group: "snapshot.kubevirt.io", Version: "v1alpha1", Resource: "virtualmachinesnapshots"},
+			Namespaced:           true,
+		}),
+		Entry("short name", "pvc", &watch.Resource{
+			GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"},
+			Namespaced:           true,
+		}),
+		Entry("cluster scoped resource", "namespaces", &watch.Resource{
+			GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+			Namespaced:           false,
+		}),
+	)
+
+	It("fails for an unknown resource", func() {
+		_, err := watch.ResolveResource(discoveryClient, "VirtualMachinePool")
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("could not find resource VirtualMachinePool"))
+	})
+})
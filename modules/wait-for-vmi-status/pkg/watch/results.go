@@ -0,0 +1,50 @@
+package watch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/constants"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// getResults returns the status.phase of the object and its status.conditions in the TYPE=STATUS[: REASON] format
+// separated by newlines. The results are empty if no object was observed or it does not report them.
+func getResults(obj *unstructured.Unstructured) map[string]string {
+	var phase string
+	var conditions []string
+
+	if obj != nil {
+		phase = getPhase(obj)
+		conditions = getConditions(obj)
+	}
+
+	return map[string]string{
+		constants.PhaseResultName:      phase,
+		constants.ConditionsResultName: strings.Join(conditions, "\n"),
+	}
+}
+
+// getConditions returns the status.conditions of the object in the TYPE=STATUS[: REASON] format
+func getConditions(obj *unstructured.Unstructured) []string {
+	var conditions []string
+
+	statusConditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, statusCondition := range statusConditions {
+		condition, ok := statusCondition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		formattedCondition := fmt.Sprintf("%v=%v", condition["type"], condition["status"])
+		if reason, _ := condition["reason"].(string); reason != "" {
+			formattedCondition += ": " + reason
+		}
+		conditions = append(conditions, formattedCondition)
+	}
+	return conditions
+}
+
+func getPhase(obj *unstructured.Unstructured) string {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	return phase
+}
@@ -0,0 +1,70 @@
+package watch_test
+
+import (
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/watch"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+var _ = Describe("Results", func() {
+	toUnstructured := func(obj interface{}) *unstructured.Unstructured {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		Expect(err).Should(Succeed())
+		return &unstructured.Unstructured{Object: content}
+	}
+
+	DescribeTable("returns phase and conditions of the last observed object", func(obj func() *unstructured.Unstructured, expectedResults map[string]string) {
+		Expect(watch.GetResults(obj())).To(Equal(expectedResults))
+	},
+		Entry("no object", func() *unstructured.Unstructured { return nil }, map[string]string{
+			"phase":      "",
+			"conditions": "",
+		}),
+		Entry("vmi without conditions", func() *unstructured.Unstructured {
+			return toUnstructured(&kubevirtv1.VirtualMachineInstance{
+				Status: kubevirtv1.VirtualMachineInstanceStatus{Phase: kubevirtv1.Scheduling},
+			})
+		}, map[string]string{
+			"phase":      "Scheduling",
+			"conditions": "",
+		}),
+		Entry("vmi with conditions", func() *unstructured.Unstructured {
+			return toUnstructured(&kubevirtv1.VirtualMachineInstance{
+				Status: kubevirtv1.VirtualMachineInstanceStatus{
+					Phase: kubevirtv1.Running,
+					Conditions: []kubevirtv1.VirtualMachineInstanceCondition{
+						{Type: kubevirtv1.VirtualMachineInstanceReady, Status: corev1.ConditionFalse, Reason: "GuestNotRunning"},
+						{Type: kubevirtv1.VirtualMachineInstanceAgentConnected, Status: corev1.ConditionTrue},
+					},
+				},
+			})
+		}, map[string]string{
+			"phase":      "Running",
+			"conditions": "Ready=False: GuestNotRunning\nAgentConnected=True",
+		}),
+		Entry("vm without phase", func() *unstructured.Unstructured {
+			return toUnstructured(&kubevirtv1.VirtualMachine{
+				Status: kubevirtv1.VirtualMachineStatus{
+					Conditions: []kubevirtv1.VirtualMachineCondition{
+						{Type: kubevirtv1.VirtualMachineReady, Status: corev1.ConditionTrue},
+					},
+				},
+			})
+		}, map[string]string{
+			"phase":      "",
+			"conditions": "Ready=True",
+		}),
+		Entry("pvc", func() *unstructured.Unstructured {
+			return toUnstructured(&corev1.PersistentVolumeClaim{
+				Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+			})
+		}, map[string]string{
+			"phase":      "Bound",
+			"conditions": "",
+		}),
+	)
+})
@@ -2,17 +2,20 @@ package watch
 
 import (
 	"fmt"
-	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/requirements"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/shared/pkg/results"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/constants"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/utils/log"
 	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/utils/parse"
 	"go.uber.org/zap"
-	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
-	api "k8s.io/kubernetes/pkg/apis/core"
-	kubevirtv1 "kubevirt.io/api/core/v1"
 	kubevirtcliv1 "kubevirt.io/client-go/kubecli"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,6 +23,13 @@ type WatchFacade struct {
 	clioptions     *parse.CLIOptions
 	kubeClient     *kubernetes.Clientset
 	kubevirtClient kubevirtcliv1.KubevirtClient
+	resource       *resource
+	quorum         *quorum
+	history        *history
+	stats          *watchStats
+
+	lastObjectLock sync.Mutex
+	lastObject     *unstructured.Unstructured
 }
 
 func NewWatchFacade(clioptions *parse.CLIOptions) (*WatchFacade, error) {
@@ -35,61 +45,199 @@ func NewWatchFacade(clioptions *parse.CLIOptions) (*WatchFacade, error) {
 		return nil, fmt.Errorf("cannot create kubevirt client: %v", err.Error())
 	}
 
-	return &WatchFacade{clioptions: clioptions, kubeClient: kubeClient, kubevirtClient: kubevirtClient}, nil
-}
+	resource, err := resolveResource(kubevirtClient.DiscoveryClient(), clioptions.GetResourceKind())
+	if err != nil {
+		return nil, err
+	}
+	log.Logger().Debug("resolved resource", zap.Stringer("resource", resource.GroupVersionResource), zap.Bool("namespaced", resource.Namespaced))
 
-func (f *WatchFacade) WaitForVMIConditions() bool {
-	successRequirements := f.clioptions.GetSuccessRequirements()
-	failureRequirements := f.clioptions.GetFailureRequirements()
+	return newWatchFacade(clioptions, kubeClient, kubevirtClient, resource), nil
+}
 
-	if len(successRequirements) == 0 && len(failureRequirements) == 0 {
-		return true
+func newWatchFacade(clioptions *parse.CLIOptions, kubeClient *kubernetes.Clientset, kubevirtClient kubevirtcliv1.KubevirtClient, resource *resource) *WatchFacade {
+	return &WatchFacade{
+		clioptions:     clioptions,
+		kubeClient:     kubeClient,
+		kubevirtClient: kubevirtClient,
+		resource:       resource,
+		quorum:         newQuorum(clioptions.GetSuccessQuorum(), clioptions.GetFailurePolicy()),
+		history:        newHistory(),
+		stats:          &watchStats{},
 	}
+}
 
-	listerWatcher := cache.NewListWatchFromClient(f.kubevirtClient.RestClient(),
-		"virtualmachineinstances",
-		f.clioptions.VirtualMachineInstanceNamespace,
-		fields.OneTermEqualSelector(api.ObjectNameField, f.clioptions.VirtualMachineInstanceName),
-	)
+// WaitForConditions waits until the success or failure condition matches the watched objects and returns true if the success quorum was reached.
+// A single object is watched by its name or multiple objects by a label selector.
+// Returns wait.ErrWaitTimeout if neither of the conditions matched before the timeout expired.
+func (f *WatchFacade) WaitForConditions() (bool, error) {
+	return f.waitForConditions(newListerWatcher(f.resourceClient(), f.clioptions.GetVirtualMachineInstanceName(), f.clioptions.GetVirtualMachineInstanceSelector()))
+}
+
+func (f *WatchFacade) waitForConditions(listerWatcher cache.ListerWatcher) (bool, error) {
+	successCondition := newCondition(f.clioptions.GetSuccessCondition(), f.clioptions.GetSuccessRequirements(), f.clioptions.GetCompiledSuccessExpression())
+	failureCondition := newCondition(f.clioptions.GetFailureCondition(), f.clioptions.GetFailureRequirements(), f.clioptions.GetCompiledFailureExpression())
+
+	if successCondition.isEmpty() && failureCondition.isEmpty() {
+		return true, nil
+	}
 
 	stop := make(chan struct{})
 	success := make(chan bool, 1)
+	var stopOnce sync.Once
 
-	eventHandler := func(obj interface{}) {
-		if len(successRequirements) > 0 {
-			log.Logger().Debug("evaluating condition", zap.String("successCondition", f.clioptions.GetSuccessCondition()))
-			if requirements.MatchesRequirements(obj, successRequirements) {
-				success <- true
-				close(stop)
-				return
+	finish := func(result bool) {
+		stopOnce.Do(func() {
+			success <- result
+			close(stop)
+		})
+	}
+
+	var listErr error
+	failList := func(err error) {
+		stopOnce.Do(func() {
+			listErr = err
+			close(stop)
+		})
+	}
+
+	// the handlers are called while the queue of the informer is locked and can not check if it has synced
+	var synced atomic.Bool
+
+	decide := func() {
+		// decide once all objects of the initial list are known
+		if synced.Load() {
+			if done, success := f.quorum.decide(); done {
+				finish(success)
 			}
 		}
+	}
 
-		if len(failureRequirements) > 0 {
-			log.Logger().Debug("evaluating condition", zap.String("failureCondition", f.clioptions.GetFailureCondition()))
-			if requirements.MatchesRequirements(obj, failureRequirements) {
-				success <- false
-				close(stop)
-			}
+	eventHandler := func(obj interface{}) {
+		object, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return
+		}
+		f.setLastObject(object)
+		if transition, ok := f.history.record(object); ok {
+			log.Logger().Info(transition.String())
+		}
+
+		state := getObjectState(object, successCondition, failureCondition)
+		log.Logger().Debug("object state", zap.String("name", object.GetName()), zap.String("state", string(state)))
+		f.quorum.update(object.GetName(), state, getPhase(object))
+		decide()
+	}
+
+	deleteHandler := func(obj interface{}) {
+		name, object := getDeletedObject(obj)
+		if name == "" {
+			log.Logger().Debug("could not get the name of the deleted object", zap.Reflect("object", obj))
+			return
+		}
+		if object != nil {
+			f.setLastObject(object)
 		}
+		if transition, ok := f.history.recordDeletion(name); ok {
+			log.Logger().Info(transition.String())
+		}
+
+		state := getDeletionState(f.clioptions.GetDeletionPolicy())
+		log.Logger().Debug("object state", zap.String("name", name), zap.String("state", string(state)), zap.Bool("deleted", true))
+		f.quorum.updateState(name, state)
+		decide()
 	}
 
-	_, controller := cache.NewInformer(listerWatcher, &kubevirtv1.VirtualMachineInstance{}, time.Second*0, cache.ResourceEventHandlerFuncs{
+	resourceName := f.resource.Resource
+	// the informer recovers from watch errors by watching or listing the objects again and the resync periodically
+	// updates the unchanged objects, so their conditions are evaluated again
+	_, controller := cache.NewInformer(newCountingListerWatcher(listerWatcher, f.stats, failList), &unstructured.Unstructured{}, f.clioptions.GetReevaluationInterval(), cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
-			log.Logger().Debug("vmi added", zap.Reflect("vmi", obj))
+			log.Logger().Debug("object added", zap.String("resource", resourceName), zap.Reflect("object", obj))
 			eventHandler(obj)
 		},
 		DeleteFunc: func(obj interface{}) {
-			log.Logger().Debug("vmi deleted", zap.Reflect("vmi", obj))
-			eventHandler(obj)
+			log.Logger().Debug("object deleted", zap.String("resource", resourceName), zap.Reflect("object", obj))
+			deleteHandler(obj)
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
-			log.Logger().Debug("vmi changed", zap.Reflect("vmi", newObj))
+			log.Logger().Debug("object changed", zap.String("resource", resourceName), zap.Reflect("object", newObj))
 			eventHandler(newObj)
 		},
 	})
 
+	if timeout := f.clioptions.GetTimeout(); timeout > 0 {
+		timer := time.AfterFunc(timeout, func() {
+			stopOnce.Do(func() {
+				log.Logger().Debug("timed out waiting for conditions", zap.Duration("timeout", timeout))
+				close(stop)
+			})
+		})
+		defer timer.Stop()
+	}
+
+	go func() {
+		if cache.WaitForCacheSync(stop, controller.HasSynced) {
+			synced.Store(true)
+			decide()
+		}
+	}()
+
 	controller.Run(stop)
+	log.Logger().Info("watch finished", zap.Object("watchStats", f.stats))
+
+	if listErr != nil {
+		return false, fmt.Errorf("could not list %v, other resources than the default ones require additional RBAC permissions for the service account of the task: %w", resourceName, listErr)
+	}
+
+	select {
+	case result := <-success:
+		return result, nil
+	default:
+		return false, wait.ErrWaitTimeout
+	}
+}
+
+// RecordResults records the phase and conditions of the last observed object, the states of all observed objects and their transitions
+func (f *WatchFacade) RecordResults() error {
+	f.lastObjectLock.Lock()
+	defer f.lastObjectLock.Unlock()
+
+	taskResults := getResults(f.lastObject)
+	taskResults[constants.StatesResultName] = f.quorum.String()
+	taskResults[constants.HistoryResultName] = f.history.String()
+
+	return results.RecordResults(taskResults)
+}
+
+func (f *WatchFacade) resourceClient() dynamic.ResourceInterface {
+	resourceClient := f.kubevirtClient.DynamicClient().Resource(f.resource.GroupVersionResource)
+	if f.resource.Namespaced {
+		return resourceClient.Namespace(f.clioptions.GetVirtualMachineInstanceNamespace())
+	}
+	return resourceClient
+}
+
+func (f *WatchFacade) setLastObject(object *unstructured.Unstructured) {
+	f.lastObjectLock.Lock()
+	defer f.lastObjectLock.Unlock()
+	f.lastObject = object
+}
+
+// getObjectState returns the state of the object according to the first condition it fulfills
+func getObjectState(object *unstructured.Unstructured, successCondition, failureCondition *condition) objectState {
+	if !successCondition.isEmpty() {
+		log.Logger().Debug("evaluating condition", zap.Stringer("successCondition", successCondition))
+		if successCondition.matches(object) {
+			return successObjectState
+		}
+	}
+
+	if !failureCondition.isEmpty() {
+		log.Logger().Debug("evaluating condition", zap.Stringer("failureCondition", failureCondition))
+		if failureCondition.matches(object) {
+			return failureObjectState
+		}
+	}
 
-	return <-success
+	return waitingObjectState
 }
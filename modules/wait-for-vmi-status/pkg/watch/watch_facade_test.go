@@ -0,0 +1,207 @@
+package watch_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/utils/parse"
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/watch"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	watchapi "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	fcache "k8s.io/client-go/tools/cache/testing"
+)
+
+var _ = Describe("WatchFacade", func() {
+	var source *fcache.FakeControllerSource
+
+	newVMI := func(name, phase string) *unstructured.Unstructured {
+		vmi := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "kubevirt.io/v1",
+			"kind":       "VirtualMachineInstance",
+			"status": map[string]interface{}{
+				"phase": phase,
+			},
+		}}
+		vmi.SetName(name)
+		vmi.SetNamespace("default")
+		vmi.SetLabels(map[string]string{"app": "test"})
+		return vmi
+	}
+
+	newWatchFacade := func(options *parse.CLIOptions) *watch.WatchFacade {
+		options.VirtualMachineInstanceNamespace = "default"
+		if options.VirtualMachineInstanceSelector == "" {
+			options.VirtualMachineInstanceName = "test"
+		}
+		if options.Timeout == "" {
+			options.Timeout = "10s"
+		}
+		Expect(options.Init()).To(Succeed())
+		return watch.NewWatchFacadeWithoutClients(options)
+	}
+
+	BeforeEach(func() {
+		source = fcache.NewFakeControllerSource()
+	})
+
+	AfterEach(func() {
+		source.Shutdown()
+	})
+
+	It("succeeds once the success condition is fulfilled", func() {
+		source.Add(newVMI("test", "Scheduling"))
+		facade := newWatchFacade(&parse.CLIOptions{SuccessCondition: "status.phase == Running", FailureCondition: "status.phase == Failed"})
+
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(100 * time.Millisecond)
+			source.Modify(newVMI("test", "Running"))
+		}()
+
+		Expect(facade.WaitForConditionsWith(source)).To(BeTrue())
+		Expect(facade.GetStates()).To(Equal("test=success: Running"))
+	})
+
+	It("fails once the failure condition is fulfilled", func() {
+		source.Add(newVMI("test", "Failed"))
+		facade := newWatchFacade(&parse.CLIOptions{SuccessCondition: "status.phase == Succeeded", FailureCondition: "status.phase == Failed"})
+
+		Expect(facade.WaitForConditionsWith(source)).To(BeFalse())
+		Expect(facade.GetStates()).To(Equal("test=failure: Failed"))
+	})
+
+	It("times out", func() {
+		source.Add(newVMI("test", "Running"))
+		facade := newWatchFacade(&parse.CLIOptions{SuccessCondition: "status.phase == Succeeded", Timeout: "200ms"})
+
+		_, err := facade.WaitForConditionsWith(source)
+		Expect(err).To(Equal(wait.ErrWaitTimeout))
+		Expect(facade.GetStates()).To(Equal("test=waiting: Running"))
+	})
+
+	It("waits for all selected objects", func() {
+		source.Add(newVMI("test-a", "Running"))
+		source.Add(newVMI("test-b", "Scheduling"))
+		facade := newWatchFacade(&parse.CLIOptions{VirtualMachineInstanceSelector: "app=test", SuccessCondition: "status.phase == Running"})
+
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(100 * time.Millisecond)
+			source.Modify(newVMI("test-b", "Running"))
+		}()
+
+		Expect(facade.WaitForConditionsWith(source)).To(BeTrue())
+		Expect(facade.GetStates()).To(Equal("test-a=success: Running\ntest-b=success: Running"))
+	})
+
+	It("fails when the object is deleted", func() {
+		source.Add(newVMI("test", "Running"))
+		facade := newWatchFacade(&parse.CLIOptions{SuccessCondition: "status.phase == Succeeded", DeletionPolicy: "fail"})
+
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(100 * time.Millisecond)
+			source.Delete(newVMI("test", "Running"))
+		}()
+
+		Expect(facade.WaitForConditionsWith(source)).To(BeFalse())
+		Expect(facade.GetStates()).To(Equal("test=failure: Running"))
+	})
+
+	It("evaluates unchanged objects again", func() {
+		source.Add(newVMI("test", "Running"))
+		facade := newWatchFacade(&parse.CLIOptions{
+			SuccessExpression:    "now > timestamp('" + time.Now().Add(300*time.Millisecond).Format(time.RFC3339Nano) + "')",
+			ReevaluationInterval: "100ms",
+		})
+
+		Expect(facade.WaitForConditionsWith(source)).To(BeTrue())
+	})
+
+	It("recovers from list errors", func() {
+		source.Add(newVMI("test", "Running"))
+		facade := newWatchFacade(&parse.CLIOptions{SuccessCondition: "status.phase == Running"})
+
+		var lists int64
+		listerWatcher := &cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if atomic.AddInt64(&lists, 1) == 1 {
+					return nil, errors.New("connection refused")
+				}
+				return source.List(options)
+			},
+			WatchFunc: source.Watch,
+		}
+
+		Expect(facade.WaitForConditionsWith(listerWatcher)).To(BeTrue())
+		Expect(facade.GetWatchStats()).To(Equal(watch.WatchStats{ListErrors: 1}))
+	})
+
+	It("fails on forbidden list", func() {
+		facade := newWatchFacade(&parse.CLIOptions{SuccessCondition: "status.phase == Running"})
+
+		listerWatcher := &cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				return nil, apierrors.NewForbidden(schema.GroupResource{Group: "kubevirt.io", Resource: "virtualmachineinstances"}, "", errors.New("access denied"))
+			},
+			WatchFunc: source.Watch,
+		}
+
+		success, err := facade.WaitForConditionsWith(listerWatcher)
+		Expect(success).To(BeFalse())
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("additional RBAC permissions"))
+		Expect(facade.GetWatchStats()).To(Equal(watch.WatchStats{ListErrors: 1}))
+	})
+
+	It("recovers from watch errors", func() {
+		source.Add(newVMI("test", "Scheduling"))
+		facade := newWatchFacade(&parse.CLIOptions{SuccessCondition: "status.phase == Running"})
+
+		var watches int64
+		listerWatcher := &cache.ListWatch{
+			ListFunc: source.List,
+			WatchFunc: func(options v1.ListOptions) (watchapi.Interface, error) {
+				if atomic.AddInt64(&watches, 1) == 1 {
+					watcher := watchapi.NewFake()
+					go func() {
+						status := apierrors.NewInternalError(errors.New("etcd is unavailable")).Status()
+						watcher.Error(&status)
+						source.Modify(newVMI("test", "Running"))
+					}()
+					return watcher, nil
+				}
+				return source.Watch(options)
+			},
+		}
+
+		Expect(facade.WaitForConditionsWith(listerWatcher)).To(BeTrue())
+		Expect(facade.GetWatchStats()).To(Equal(watch.WatchStats{WatchErrors: 1, RestartedWatches: 1}))
+	})
+
+	It("recovers from expired watches", func() {
+		source.Add(newVMI("test", "Scheduling"))
+		facade := newWatchFacade(&parse.CLIOptions{SuccessCondition: "status.phase == Running"})
+
+		go func() {
+			defer GinkgoRecover()
+			// watches closed in less than a second are not restarted but the objects are listed again
+			time.Sleep(1500 * time.Millisecond)
+			// the change is missed by the watch and its resource version is not known anymore after the restart
+			source.ModifyDropWatch(newVMI("test", "Running"))
+			source.ResetWatch()
+		}()
+
+		Expect(facade.WaitForConditionsWith(source)).To(BeTrue())
+		Expect(facade.GetWatchStats()).To(Equal(watch.WatchStats{ExpiredWatches: 1, RestartedWatches: 2}))
+	})
+})
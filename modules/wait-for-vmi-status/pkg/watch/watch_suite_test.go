@@ -0,0 +1,18 @@
+package watch_test
+
+import (
+	"testing"
+
+	"github.com/kubevirt/kubevirt-tekton-tasks/modules/wait-for-vmi-status/pkg/utilstest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestWatch(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Watch Suite")
+}
+
+var _ = BeforeSuite(func() {
+	utilstest.SetupTestSuite()
+})